@@ -0,0 +1,135 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// reader of comma (or tab) separated values, one column is the unique key,
+// other columns are components of vector.
+type table struct {
+	r         *csv.Reader
+	key       int
+	header    int64
+	err       error
+	uniqueKey []byte
+	vector    []float32
+}
+
+func newCSV(r io.Reader, comma rune, opts Options) (*table, error) {
+	s := &table{r: csv.NewReader(r)}
+	s.r.Comma = comma
+	s.r.ReuseRecord = true
+	s.r.TrimLeadingSpace = true
+
+	key, err := strconv.Atoi(opts.KeyColumn)
+	switch {
+	case opts.KeyColumn == "":
+		key = 0
+	case err == nil && key < 0:
+		return nil, fmt.Errorf("invalid key column %d", key)
+	case err != nil:
+		// the column is defined by name, it requires the header
+		key = -1
+	}
+
+	if opts.Header || key == -1 {
+		header, err := s.r.Read()
+		if err != nil {
+			return nil, fmt.Errorf("invalid header: %w", err)
+		}
+
+		if key == -1 {
+			key = slices.Index(header, opts.KeyColumn)
+			if key == -1 {
+				return nil, fmt.Errorf("key column %q is not found in header (%s)", opts.KeyColumn, strings.Join(header, ", "))
+			}
+		}
+	}
+
+	s.key = key
+	s.header = s.r.InputOffset()
+	return s, nil
+}
+
+func (s *table) Err() error        { return s.err }
+func (s *table) UniqueKey() []byte { return s.uniqueKey }
+func (s *table) Vector() []float32 { return s.vector }
+func (s *table) Offset() int64     { return s.r.InputOffset() }
+
+func (s *table) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	row, err := s.r.Read()
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		return false
+	}
+
+	if s.key >= len(row) {
+		line, _ := s.r.FieldPos(0)
+		s.err = fmt.Errorf("line %d: key column %d is out of row (%d columns)", line, s.key, len(row))
+		return false
+	}
+
+	key, err := DecodeKey(row[s.key])
+	if err != nil {
+		line, col := s.r.FieldPos(s.key)
+		s.err = fmt.Errorf("line %d, column %d: invalid key: %w", line, col, err)
+		return false
+	}
+	s.uniqueKey = key
+
+	s.vector = make([]float32, 0, len(row)-1)
+	for i, x := range row {
+		if i == s.key {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(x), 32)
+		if err != nil {
+			line, col := s.r.FieldPos(i)
+			s.err = fmt.Errorf("line %d, column %d: %w", line, col, err)
+			return false
+		}
+		s.vector = append(s.vector, float32(v))
+	}
+
+	return true
+}
+
+// rows are not fixed size, the input is skipped by reading rows. The offset
+// within the header (e.g. 0) is the beginning of rows, nothing to skip.
+func (s *table) Skip(offset int64) error {
+	if offset <= s.header {
+		return nil
+	}
+
+	for s.r.InputOffset() < offset {
+		if _, err := s.r.Read(); err != nil {
+			return err
+		}
+	}
+
+	if s.r.InputOffset() != offset {
+		return fmt.Errorf("unable to skip input to %d, it is not a boundary of row", offset)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSV(t *testing.T) {
+	r, err := NewReader(strings.NewReader("a,1,2\n0x6263,3,4\n"), "a.csv", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"a", "bc"})
+	expect(t, "vectors", vectors, [][]float32{{1, 2}, {3, 4}})
+}
+
+func TestCSVHeader(t *testing.T) {
+	input := "x\ty\tid\n1\t2\ta\n3\t4\tb\n"
+
+	r, err := NewReader(strings.NewReader(input), "a.tsv", Options{KeyColumn: "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fresh upload skips to the beginning of input, the header is consumed
+	if err := r.Skip(0); err != nil {
+		t.Fatalf("unable to skip to 0: %v", err)
+	}
+
+	r.Scan()
+	offset := r.Offset()
+	expect(t, "key", string(r.UniqueKey()), "a")
+
+	r, _ = NewReader(strings.NewReader(input), "a.tsv", Options{KeyColumn: "id"})
+	if err := r.Skip(offset); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"b"})
+	expect(t, "vectors", vectors, [][]float32{{3, 4}})
+}
+
+func TestCSVInvalid(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("x,y\n"), "a.csv", Options{KeyColumn: "id"}); err == nil {
+		t.Errorf("unknown key column is accepted")
+	}
+
+	for name, input := range map[string]string{
+		"number": "a,1,x\n",
+		"key":    "0xzz,1,2\n",
+	} {
+		r, _ := NewReader(strings.NewReader(input), "a.csv", Options{})
+		for r.Scan() {
+		}
+		if r.Err() == nil {
+			t.Errorf("invalid %s is accepted", name)
+		}
+	}
+}
@@ -10,57 +10,260 @@ package encoding
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"strconv"
+	"path/filepath"
 	"strings"
 )
 
-type Scanner struct {
-	r         *bufio.Scanner
-	err       error
-	uniqueKey []byte
-	vector    []float32
+// Formats of vector files
+const (
+	FORMAT_TEXT  = "text"
+	FORMAT_FVECS = "fvecs"
+	FORMAT_BVECS = "bvecs"
+	FORMAT_IVECS = "ivecs"
+	FORMAT_NPY   = "npy"
+	FORMAT_CSV   = "csv"
+	FORMAT_TSV   = "tsv"
+	FORMAT_JSONL = "jsonl"
+)
+
+// Reader of vectors from the file
+type Reader interface {
+	// Scan advances the reader to the next vector
+	Scan() bool
+
+	// UniqueKey of the vector. Formats without keys (e.g. fvecs, npy) use
+	// the index of the vector within the file as the key.
+	UniqueKey() []byte
+
+	// Vector read by the last call to Scan
+	Vector() []float32
+
+	// Offset of input (bytes) consumed by scanned vectors
+	Offset() int64
+
+	// Skip the input until the offset, which is earlier obtained from Offset.
+	Skip(offset int64) error
+
+	// Err returns the first error encountered by the reader
+	Err() error
+}
+
+// Options of the reader
+type Options struct {
+	// Format of the file, it is derived from the extension if not defined
+	Format string
+
+	// Column of CSV/TSV file with the unique key, either index (0-based) or
+	// the name of column. The vector is read from other columns.
+	KeyColumn string
+
+	// CSV/TSV file starts with header
+	Header bool
+}
+
+// FormatOf returns the format of file, either explicitly defined or derived
+// from the extension. The empty format is returned if the extension is not
+// known, the format is sniffed from the content in this case.
+func FormatOf(file string, format string) (string, error) {
+	if format != "" {
+		switch format {
+		case FORMAT_TEXT, FORMAT_FVECS, FORMAT_BVECS, FORMAT_IVECS, FORMAT_NPY, FORMAT_CSV, FORMAT_TSV, FORMAT_JSONL:
+			return format, nil
+		default:
+			return "", fmt.Errorf("format %q is not supported, use one of text, fvecs, bvecs, ivecs, npy, csv, tsv or jsonl", format)
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".txt":
+		return FORMAT_TEXT, nil
+	case ".fvecs":
+		return FORMAT_FVECS, nil
+	case ".bvecs":
+		return FORMAT_BVECS, nil
+	case ".ivecs":
+		return FORMAT_IVECS, nil
+	case ".npy":
+		return FORMAT_NPY, nil
+	case ".csv":
+		return FORMAT_CSV, nil
+	case ".tsv":
+		return FORMAT_TSV, nil
+	case ".json", ".jsonl", ".ndjson":
+		return FORMAT_JSONL, nil
+	default:
+		return "", nil
+	}
 }
 
-func New(r io.Reader) *Scanner {
-	return &Scanner{
-		r: bufio.NewScanner(r),
+// Sniff the format from the first line of input: NumPy array, JSON lines
+// or text. Binary formats without magic (e.g. fvecs) are not recognized.
+// The line starting with '{' is JSON unless it is entirely peeked and invalid,
+// lines longer than the buffer are not validated.
+func Sniff(r *bufio.Reader) string {
+	head, err := r.Peek(r.Size())
+	if bytes.HasPrefix(head, npyMagic) {
+		return FORMAT_NPY
 	}
+
+	for len(head) > 0 {
+		line, complete := head, err != nil
+		if i := bytes.IndexByte(head, '\n'); i != -1 {
+			line, head, complete = head[:i], head[i+1:], true
+		} else {
+			head = nil
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		if line[0] == '{' && (!complete || json.Valid(line)) {
+			return FORMAT_JSONL
+		}
+		return FORMAT_TEXT
+	}
+
+	return FORMAT_TEXT
 }
 
-func (s *Scanner) Err() error        { return s.r.Err() }
-func (s *Scanner) UniqueKey() []byte { return s.uniqueKey }
-func (s *Scanner) Vector() []float32 { return s.vector }
+// NewReader creates reader of vectors for the file
+func NewReader(r io.Reader, file string, opts Options) (Reader, error) {
+	format, err := FormatOf(file, opts.Format)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *Scanner) Scan() bool {
-	if !s.r.Scan() {
-		return false
+	buf := bufio.NewReaderSize(r, 64*1024)
+	if format == "" {
+		format = Sniff(buf)
 	}
 
-	seq := strings.Split(s.r.Text(), " ")
+	switch format {
+	case FORMAT_FVECS:
+		return newVecs(buf, 4, fromFloat32), nil
+	case FORMAT_BVECS:
+		return newVecs(buf, 1, fromUint8), nil
+	case FORMAT_IVECS:
+		return newVecs(buf, 4, fromInt32), nil
+	case FORMAT_NPY:
+		return newNpy(buf)
+	case FORMAT_CSV:
+		return newCSV(buf, ',', opts)
+	case FORMAT_TSV:
+		return newCSV(buf, '\t', opts)
+	case FORMAT_JSONL:
+		return newJSONL(buf), nil
+	default:
+		return New(buf), nil
+	}
+}
+
+//------------------------------------------------------------------------------
 
-	f32 := make([]float32, len(seq)-1)
-	for i := 1; i < len(seq); i++ {
-		v, err := strconv.ParseFloat(seq[i], 32)
+// input is buffered reader, which tracks the position of stream
+type input struct {
+	r   *bufio.Reader
+	pos int64
+}
+
+func newInput(r io.Reader) *input {
+	return &input{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+func (in *input) Read(p []byte) (int, error) {
+	n, err := in.r.Read(p)
+	in.pos += int64(n)
+	return n, err
+}
+
+// reads exactly len(p) bytes, io.EOF is returned only if no bytes are read
+func (in *input) ReadFull(p []byte) error {
+	n, err := io.ReadFull(in.r, p)
+	in.pos += int64(n)
+	return err
+}
+
+// reads the line of any length, the line excludes end of line
+func (in *input) ReadLine() ([]byte, error) {
+	line, err := in.r.ReadBytes('\n')
+	in.pos += int64(len(line))
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+
+	line = trimEOL(line)
+	return line, err
+}
+
+func (in *input) Skip(offset int64) error {
+	if offset < in.pos {
+		return fmt.Errorf("unable to skip input backward to %d, already at %d", offset, in.pos)
+	}
+
+	for offset > in.pos {
+		n, err := in.r.Discard(int(min(offset-in.pos, 1<<30)))
+		in.pos += int64(n)
 		if err != nil {
-			s.err = err
-			return false
+			return err
 		}
-		f32[i-1] = float32(v)
 	}
-	s.vector = f32
 
-	if strings.HasPrefix(seq[0], "0x") {
-		x, err := hex.DecodeString(seq[0][2:])
+	return nil
+}
+
+func trimEOL(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line
+}
+
+// The maximum length of unique key (bytes)
+const MAX_UNIQUE_KEY = 32
+
+// DecodeKey decodes unique key, the key is hex encoded if it starts with "0x"
+// prefix. The key shall not be empty or exceed MAX_UNIQUE_KEY bytes.
+func DecodeKey(key string) ([]byte, error) {
+	val := []byte(key)
+	if strings.HasPrefix(key, "0x") {
+		x, err := hex.DecodeString(key[2:])
 		if err != nil {
-			s.err = err
-			return false
+			return nil, fmt.Errorf("invalid hex key %q", key)
 		}
-		s.uniqueKey = x
-	} else {
-		s.uniqueKey = []byte(seq[0])
+		val = x
+	}
+
+	if len(val) == 0 {
+		return nil, errors.New("empty key")
 	}
 
-	return true
+	if len(val) > MAX_UNIQUE_KEY {
+		return nil, fmt.Errorf("key %q is %d bytes, it exceeds %d bytes", key, len(val), MAX_UNIQUE_KEY)
+	}
+
+	return val, nil
+}
+
+// SyntaxError is an error at the position of input
+type SyntaxError struct {
+	Line   int
+	Column int
+	Err    error
 }
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
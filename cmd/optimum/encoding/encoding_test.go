@@ -0,0 +1,94 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reads all vectors, the reader shall not fail
+func readAll(t *testing.T, r Reader) ([]string, [][]float32) {
+	t.Helper()
+
+	var keys []string
+	var vectors [][]float32
+	for r.Scan() {
+		keys = append(keys, string(r.UniqueKey()))
+		vectors = append(vectors, r.Vector())
+	}
+
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return keys, vectors
+}
+
+func expect[T any](t *testing.T, name string, actual, expected T) {
+	t.Helper()
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected %s\n  actual: %v\nexpected: %v", name, actual, expected)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for file, format := range map[string]string{
+		"a.txt":    FORMAT_TEXT,
+		"a.fvecs":  FORMAT_FVECS,
+		"a.NPY":    FORMAT_NPY,
+		"a.json":   FORMAT_JSONL,
+		"a.tsv":    FORMAT_TSV,
+		"a.vector": "",
+	} {
+		actual, err := FormatOf(file, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect(t, file, actual, format)
+	}
+
+	if _, err := FormatOf("a.txt", "parquet"); err == nil {
+		t.Errorf("unsupported format is accepted")
+	}
+}
+
+func TestSniff(t *testing.T) {
+	for input, format := range map[string]string{
+		"\n{\"id\": \"a\", \"v\": [1]}\n": FORMAT_JSONL,
+		"a 0.1 0.2\n":                     FORMAT_TEXT,
+		"\x93NUMPY\x01\x00":               FORMAT_NPY,
+		"":                                FORMAT_TEXT,
+		"  \t{\"id\": \"a\"}":             FORMAT_JSONL,
+		"{not json}\n":                    FORMAT_TEXT,
+		"{a} 0.1 0.2\n{\"id\": \"a\"}\n":  FORMAT_TEXT,
+	} {
+		expect(t, strings.TrimSpace(input), Sniff(bufio.NewReader(strings.NewReader(input))), format)
+	}
+}
+
+func TestSniffLongLine(t *testing.T) {
+	vector := strings.Repeat("0.123456789, ", 16*1024)
+	input := "\n{\"id\": \"a\", \"v\": [" + vector + "1]}\n"
+
+	r := bufio.NewReaderSize(strings.NewReader(input), 64*1024)
+	expect(t, "format", Sniff(r), FORMAT_JSONL)
+
+	reader, err := NewReader(strings.NewReader(input), "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, vectors := readAll(t, reader)
+	expect(t, "keys", keys, []string{"a"})
+	expect(t, "dimension", len(vectors[0]), 16*1024+1)
+}
@@ -0,0 +1,109 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// reader of JSON lines, each line is object {"id": "...", "v": [...]}. The
+// object optionally carries on tuning of the query (see Tuning). Blank lines
+// are skipped.
+type jsonl struct {
+	r         *input
+	line      int
+	err       error
+	uniqueKey []byte
+	vector    []float32
+	tuning    Tuning
+}
+
+func newJSONL(r io.Reader) *jsonl {
+	return &jsonl{r: newInput(r)}
+}
+
+func (s *jsonl) Err() error        { return s.err }
+func (s *jsonl) UniqueKey() []byte { return s.uniqueKey }
+func (s *jsonl) Vector() []float32 { return s.vector }
+func (s *jsonl) Offset() int64     { return s.r.pos }
+func (s *jsonl) Tuning() Tuning    { return s.tuning }
+
+func (s *jsonl) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	var line []byte
+	for len(bytes.TrimSpace(line)) == 0 {
+		var err error
+		if line, err = s.r.ReadLine(); err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+		s.line++
+	}
+
+	var obj struct {
+		ID string    `json:"id"`
+		V  []float32 `json:"v"`
+		Tuning
+	}
+	if err := json.Unmarshal(line, &obj); err != nil {
+		s.err = fmt.Errorf("line %d: %w", s.line, err)
+		return false
+	}
+
+	if obj.ID == "" {
+		s.err = fmt.Errorf("line %d: id is missing", s.line)
+		return false
+	}
+
+	if len(obj.V) == 0 {
+		s.err = fmt.Errorf("line %d: vector is empty", s.line)
+		return false
+	}
+
+	key, err := DecodeKey(obj.ID)
+	if err != nil {
+		s.err = fmt.Errorf("line %d: invalid id: %w", s.line, err)
+		return false
+	}
+
+	if obj.K < 0 || obj.EfSearch < 0 || obj.Distance < 0 {
+		s.err = fmt.Errorf("line %d: k, efSearch and distance shall not be negative", s.line)
+		return false
+	}
+
+	s.uniqueKey = key
+	s.vector = obj.V
+	s.tuning = obj.Tuning
+
+	return true
+}
+
+// lines are skipped without decoding, it keeps line numbers
+func (s *jsonl) Skip(offset int64) error {
+	for s.r.pos < offset {
+		if _, err := s.r.ReadLine(); err != nil {
+			return err
+		}
+		s.line++
+	}
+
+	if s.r.pos != offset {
+		return fmt.Errorf("unable to skip input to %d, it is not a boundary of line", offset)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONL(t *testing.T) {
+	input := `{"id": "a", "v": [1, 2]}
+{"id": "0x6263", "v": [3, 4], "k": 10, "efSearch": 200}
+`
+	r, err := NewReader(strings.NewReader(input), "a.jsonl", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Skip(0); err != nil {
+		t.Fatalf("unable to skip to 0: %v", err)
+	}
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"a", "bc"})
+	expect(t, "vectors", vectors, [][]float32{{1, 2}, {3, 4}})
+	expect(t, "tuning", r.(Tuner).Tuning(), Tuning{K: 10, EfSearch: 200})
+}
+
+func TestJSONLBlankLines(t *testing.T) {
+	input := "\n{\"id\": \"a\", \"v\": [1, 2]}\n  \t\r\n\n{\"id\": \"b\", \"v\": [3, 4]}\n\n \n"
+
+	r, err := NewReader(strings.NewReader(input), "a.jsonl", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"a", "b"})
+	expect(t, "vectors", vectors, [][]float32{{1, 2}, {3, 4}})
+	expect(t, "offset", r.Offset(), int64(len(input)))
+}
+
+func TestJSONLLineOfError(t *testing.T) {
+	input := "{\"id\": \"a\", \"v\": [1]}\n\n{\"id\": \"b\", \"v\": []}\n"
+
+	r, _ := NewReader(strings.NewReader(input), "a.jsonl", Options{})
+	for r.Scan() {
+	}
+
+	if r.Err() == nil || !strings.HasPrefix(r.Err().Error(), "line 3:") {
+		t.Errorf("unexpected error %v", r.Err())
+	}
+}
+
+func TestJSONLInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"json":   `{"id": "a", "v": [1, 2]`,
+		"id":     `{"v": [1, 2]}`,
+		"key":    `{"id": "0xzz", "v": [1, 2]}`,
+		"tuning": `{"id": "a", "v": [1, 2], "k": -1}`,
+		"empty":  `{"id": "a", "v": []}`,
+		"vector": `{"id": "a"}`,
+	} {
+		r, _ := NewReader(strings.NewReader(input), "a.jsonl", Options{})
+		for r.Scan() {
+		}
+		if r.Err() == nil {
+			t.Errorf("invalid %s is accepted", name)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"fmt"
+	"io"
+)
+
+// Keys is scanner of keys, each line is the key. Blank lines and comments
+// (lines starting with #) are skipped.
+type Keys struct {
+	r    *input
+	line int
+	err  error
+	text string
+}
+
+func NewKeys(r io.Reader) *Keys {
+	return &Keys{r: newInput(r)}
+}
+
+func (s *Keys) Err() error    { return s.err }
+func (s *Keys) Offset() int64 { return s.r.pos }
+
+// Text of the key as is
+func (s *Keys) Text() string { return s.text }
+
+// UniqueKey decodes the key, it is hex encoded if it starts with "0x" prefix.
+func (s *Keys) UniqueKey() ([]byte, error) {
+	key, err := DecodeKey(s.text)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", s.line, err)
+	}
+
+	return key, nil
+}
+
+func (s *Keys) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for {
+		line, err := s.r.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+		s.line++
+
+		key, at := token(line, 0)
+		if len(key) == 0 || key[0] == '#' {
+			continue
+		}
+
+		if tail, end := token(line, at); len(tail) != 0 {
+			s.err = &SyntaxError{Line: s.line, Column: end - len(tail) + 1, Err: fmt.Errorf("unexpected %q after key", tail)}
+			return false
+		}
+
+		s.text = string(key)
+		return true
+	}
+}
@@ -0,0 +1,187 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// npy is reader of NumPy array files, it supports two dimensional arrays of
+// little-endian float32 or float16 in C order. The row is a vector.
+type npy struct {
+	r         *input
+	header    int64
+	rows      int
+	dim       int
+	size      int
+	decode    func([]byte, []float32)
+	err       error
+	seq       int
+	uniqueKey []byte
+	vector    []float32
+	buf       []byte
+}
+
+var (
+	npyMagic   = []byte("\x93NUMPY")
+	npyDescr   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape'\s*:\s*\(\s*(\d+)\s*,\s*(\d+)\s*,?\s*\)`)
+)
+
+func newNpy(r io.Reader) (*npy, error) {
+	s := &npy{r: newInput(r)}
+
+	var preamble [8]byte
+	if err := s.r.ReadFull(preamble[:]); err != nil {
+		return nil, fmt.Errorf("npy: invalid header: %w", err)
+	}
+
+	if !bytes.Equal(preamble[:6], npyMagic) {
+		return nil, errors.New("npy: invalid magic string, it is not NumPy array")
+	}
+
+	var length int
+	switch preamble[6] {
+	case 1:
+		var b [2]byte
+		if err := s.r.ReadFull(b[:]); err != nil {
+			return nil, fmt.Errorf("npy: invalid header: %w", err)
+		}
+		length = int(binary.LittleEndian.Uint16(b[:]))
+	case 2, 3:
+		var b [4]byte
+		if err := s.r.ReadFull(b[:]); err != nil {
+			return nil, fmt.Errorf("npy: invalid header: %w", err)
+		}
+		length = int(binary.LittleEndian.Uint32(b[:]))
+	default:
+		return nil, fmt.Errorf("npy: version %d.%d is not supported", preamble[6], preamble[7])
+	}
+
+	header := make([]byte, length)
+	if err := s.r.ReadFull(header); err != nil {
+		return nil, fmt.Errorf("npy: invalid header: %w", err)
+	}
+
+	descr := npyDescr.FindSubmatch(header)
+	if descr == nil {
+		return nil, errors.New("npy: header misses descr")
+	}
+
+	switch string(descr[1]) {
+	case "<f4":
+		s.size, s.decode = 4, fromFloat32
+	case "<f2":
+		s.size, s.decode = 2, fromFloat16
+	default:
+		return nil, fmt.Errorf("npy: dtype %s is not supported, use float32 (<f4) or float16 (<f2)", descr[1])
+	}
+
+	if fortran := npyFortran.FindSubmatch(header); fortran != nil && string(fortran[1]) == "True" {
+		return nil, errors.New("npy: fortran order is not supported, use C order")
+	}
+
+	shape := npyShape.FindSubmatch(header)
+	if shape == nil {
+		return nil, errors.New("npy: only two dimensional arrays (vectors x dimension) are supported")
+	}
+
+	s.rows, _ = strconv.Atoi(string(shape[1]))
+	s.dim, _ = strconv.Atoi(string(shape[2]))
+	if s.dim <= 0 || s.dim > maxDimension {
+		return nil, fmt.Errorf("npy: invalid dimension %d", s.dim)
+	}
+
+	s.header = s.r.pos
+	s.buf = make([]byte, s.dim*s.size)
+
+	return s, nil
+}
+
+func (s *npy) Err() error        { return s.err }
+func (s *npy) UniqueKey() []byte { return s.uniqueKey }
+func (s *npy) Vector() []float32 { return s.vector }
+func (s *npy) Offset() int64     { return s.r.pos }
+
+func (s *npy) Scan() bool {
+	if s.err != nil || s.seq >= s.rows {
+		return false
+	}
+
+	if err := s.r.ReadFull(s.buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		s.err = fmt.Errorf("npy: vector %d: %w", s.seq, err)
+		return false
+	}
+
+	s.vector = make([]float32, s.dim)
+	s.decode(s.buf, s.vector)
+	s.uniqueKey = []byte(strconv.Itoa(s.seq))
+	s.seq++
+
+	return true
+}
+
+// rows are fixed size, the input is skipped without decoding. The offset
+// within the header (e.g. 0) is the beginning of rows, nothing to skip.
+func (s *npy) Skip(offset int64) error {
+	if offset <= s.header {
+		return nil
+	}
+
+	row := int64(len(s.buf))
+	if (offset-s.header)%row != 0 {
+		return fmt.Errorf("npy: unable to skip input to %d, it is not a boundary of vector", offset)
+	}
+
+	if err := s.r.Skip(offset); err != nil {
+		return err
+	}
+
+	s.seq = int((offset - s.header) / row)
+	return nil
+}
+
+// IEEE 754 half precision to single precision
+func fromFloat16(b []byte, v []float32) {
+	for i := range v {
+		h := uint32(binary.LittleEndian.Uint16(b[i*2:]))
+
+		sign := (h >> 15) << 31
+		exp := (h >> 10) & 0x1f
+		frac := h & 0x3ff
+
+		switch {
+		case exp == 0x1f:
+			// infinity or NaN
+			v[i] = math.Float32frombits(sign | 0x7f800000 | frac<<13)
+		case exp != 0:
+			v[i] = math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+		case frac == 0:
+			v[i] = math.Float32frombits(sign)
+		default:
+			// subnormal number
+			f := float32(frac) / (1 << 24)
+			if sign != 0 {
+				f = -f
+			}
+			v[i] = f
+		}
+	}
+}
@@ -0,0 +1,116 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// NumPy array of float32 with the shape (rows, dim)
+func npyOf(descr string, rows, dim int, data any) []byte {
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%d, %d), }", descr, rows, dim)
+	// header is padded to 64 bytes alignment, it ends with new line
+	header += strings.Repeat(" ", 63-(10+len(header))%64) + "\n"
+
+	var buf bytes.Buffer
+	buf.Write(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	binary.Write(&buf, binary.LittleEndian, data)
+	return buf.Bytes()
+}
+
+func TestNpy(t *testing.T) {
+	file := npyOf("<f4", 2, 2, []float32{1, 2, 3, 4})
+
+	r, err := NewReader(bytes.NewReader(file), "a.npy", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fresh upload skips to the beginning of input
+	if err := r.Skip(0); err != nil {
+		t.Fatalf("unable to skip to 0: %v", err)
+	}
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"0", "1"})
+	expect(t, "vectors", vectors, [][]float32{{1, 2}, {3, 4}})
+	expect(t, "offset", r.Offset(), int64(len(file)))
+}
+
+func TestNpyFloat16(t *testing.T) {
+	// 1.0, -2.0, 0.5, 0.0
+	file := npyOf("<f2", 1, 4, []uint16{0x3c00, 0xc000, 0x3800, 0x0000})
+
+	r, err := NewReader(bytes.NewReader(file), "a.npy", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, vectors := readAll(t, r)
+	expect(t, "vectors", vectors, [][]float32{{1, -2, 0.5, 0}})
+}
+
+func TestNpyResume(t *testing.T) {
+	file := npyOf("<f4", 3, 2, []float32{1, 2, 3, 4, 5, 6})
+
+	r, err := NewReader(bytes.NewReader(file), "a.npy", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Scan()
+	offset := r.Offset()
+
+	r, err = NewReader(bytes.NewReader(file), "a.npy", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Skip(offset); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"1", "2"})
+	expect(t, "vectors", vectors, [][]float32{{3, 4}, {5, 6}})
+
+	r, _ = NewReader(bytes.NewReader(file), "a.npy", Options{})
+	if err := r.Skip(offset + 1); err == nil {
+		t.Errorf("skip within the vector is accepted")
+	}
+}
+
+func TestNpyInvalid(t *testing.T) {
+	for name, file := range map[string][]byte{
+		"dtype":     npyOf("<f8", 1, 1, []float64{1}),
+		"magic":     []byte("NUMPY\x01\x00"),
+		"dimension": npyOf("<f4", 1, 0, []float32{}),
+	} {
+		if _, err := NewReader(bytes.NewReader(file), "a.npy", Options{}); err == nil {
+			t.Errorf("invalid %s is accepted", name)
+		}
+	}
+
+	file := npyOf("<f4", 2, 2, []float32{1, 2, 3})
+	r, err := NewReader(bytes.NewReader(file), "a.npy", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r.Scan() {
+	}
+	if r.Err() == nil {
+		t.Errorf("truncated array is accepted")
+	}
+}
@@ -0,0 +1,108 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Tuning of the query, it overrides defaults of command per query
+type Tuning struct {
+	K        int     `json:"k,omitempty"`
+	EfSearch int     `json:"efSearch,omitempty"`
+	Distance float32 `json:"distance,omitempty"`
+}
+
+// Tuner is implemented by readers of formats that carry on tuning of
+// queries (e.g. jsonl).
+type Tuner interface {
+	Tuning() Tuning
+}
+
+// Queries is scanner of text queries. Each line of the input is either text
+// or json object {"text": "...", "k": 10, "efSearch": 100, "distance": 0.5},
+// where tuning attributes are optional. Blank lines are skipped.
+type Queries struct {
+	r      *input
+	json   bool
+	line   int
+	err    error
+	text   string
+	tuning Tuning
+}
+
+// NewQueries creates scanner of text queries (json = false) or json objects
+func NewQueries(r io.Reader, json bool) *Queries {
+	return &Queries{r: newInput(r), json: json}
+}
+
+func (s *Queries) Err() error     { return s.err }
+func (s *Queries) Text() string   { return s.text }
+func (s *Queries) Tuning() Tuning { return s.tuning }
+
+func (s *Queries) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for {
+		line, err := s.r.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+		s.line++
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		if !s.json {
+			s.text, s.tuning = string(line), Tuning{}
+			return true
+		}
+
+		if err := s.parse(line); err != nil {
+			s.err = fmt.Errorf("line %d: %w", s.line, err)
+			return false
+		}
+
+		return true
+	}
+}
+
+func (s *Queries) parse(line []byte) error {
+	var obj struct {
+		Text string `json:"text"`
+		Tuning
+	}
+
+	codec := json.NewDecoder(bytes.NewReader(line))
+	codec.DisallowUnknownFields()
+	if err := codec.Decode(&obj); err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace([]byte(obj.Text))) == 0 {
+		return errors.New("text is empty")
+	}
+
+	if obj.K < 0 || obj.EfSearch < 0 || obj.Distance < 0 {
+		return errors.New("k, efSearch and distance shall not be negative")
+	}
+
+	s.text, s.tuning = obj.Text, obj.Tuning
+	return nil
+}
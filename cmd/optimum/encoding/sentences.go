@@ -0,0 +1,177 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/fogfish/schemaorg"
+	"github.com/kshard/optimum/sentences"
+)
+
+// The maximum length of text block (bytes)
+const MAX_TEXT = 4 * 1024
+
+// SentencesFormatOf returns the format of textual corpus, either text or
+// jsonl. The format is explicitly defined, derived from the extension or
+// sniffed from the first line of input.
+func SentencesFormatOf(r *bufio.Reader, file string, format string) (string, error) {
+	switch format {
+	case FORMAT_TEXT, FORMAT_JSONL:
+		return format, nil
+	case "json":
+		return FORMAT_JSONL, nil
+	case "":
+	default:
+		return "", fmt.Errorf("format %q is not supported, use one of text or jsonl", format)
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".txt":
+		return FORMAT_TEXT, nil
+	case ".json", ".jsonl", ".ndjson":
+		return FORMAT_JSONL, nil
+	}
+
+	if Sniff(r) == FORMAT_JSONL {
+		return FORMAT_JSONL, nil
+	}
+
+	return FORMAT_TEXT, nil
+}
+
+// Sentences is scanner of textual corpus. Each line of the input is either
+// text block or json object (see sentences.Sentence). Blank lines are
+// skipped. The scanner validates each line strictly, the invalid
+// line does not stop the scanner, it is reported by Invalid.
+type Sentences struct {
+	r        *input
+	json     bool
+	line     int
+	err      error
+	invalid  error
+	bytes    []byte
+	sentence sentences.Sentence
+}
+
+// NewSentences creates scanner of text blocks (json = false) or json objects
+func NewSentences(r io.Reader, json bool) *Sentences {
+	return &Sentences{r: newInput(r), json: json}
+}
+
+func (s *Sentences) Err() error                   { return s.err }
+func (s *Sentences) Sentence() sentences.Sentence { return s.sentence }
+func (s *Sentences) Offset() int64                { return s.r.pos }
+
+// Line number of the last scanned line
+func (s *Sentences) Line() int { return s.line }
+
+// Bytes of the last scanned line
+func (s *Sentences) Bytes() []byte { return s.bytes }
+
+// Invalid returns the error if the last scanned line is invalid
+func (s *Sentences) Invalid() error { return s.invalid }
+
+func (s *Sentences) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for {
+		line, err := s.r.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+		s.line++
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		s.bytes = line
+		s.sentence, s.invalid = s.parse(line)
+		if s.invalid != nil {
+			s.invalid = fmt.Errorf("line %d: %w", s.line, s.invalid)
+		}
+
+		return true
+	}
+}
+
+func (s *Sentences) parse(line []byte) (sentences.Sentence, error) {
+	if !s.json {
+		sentence := sentences.Sentence{Text: schemaorg.Text(line)}
+		return sentence, validate(sentence)
+	}
+
+	var sentence sentences.Sentence
+
+	codec := json.NewDecoder(bytes.NewReader(line))
+	codec.DisallowUnknownFields()
+	if err := codec.Decode(&sentence); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return sentence, fmt.Errorf("malformed json: %w", err)
+		}
+		return sentence, err
+	}
+
+	if _, err := codec.Token(); err != io.EOF {
+		return sentence, errors.New("malformed json: unexpected data after object")
+	}
+
+	return sentence, validate(sentence)
+}
+
+func validate(sentence sentences.Sentence) error {
+	text := strings.TrimSpace(string(sentence.Text))
+	if len(text) == 0 {
+		return errors.New("text is empty")
+	}
+
+	if len(sentence.Text) > MAX_TEXT {
+		return fmt.Errorf("text is %d bytes, it exceeds %d bytes", len(sentence.Text), MAX_TEXT)
+	}
+
+	if sentence.IsPartOf != "" {
+		uri, err := url.Parse(string(sentence.IsPartOf))
+		if err != nil || uri.Scheme == "" || uri.Host == "" {
+			return fmt.Errorf("invalid isPartOf url %q", sentence.IsPartOf)
+		}
+	}
+
+	return nil
+}
+
+// lines are skipped without decoding, it keeps line numbers
+func (s *Sentences) Skip(offset int64) error {
+	for s.r.pos < offset {
+		if _, err := s.r.ReadLine(); err != nil {
+			return err
+		}
+		s.line++
+	}
+
+	if s.r.pos != offset {
+		return fmt.Errorf("unable to skip input to %d, it is not a boundary of line", offset)
+	}
+
+	return nil
+}
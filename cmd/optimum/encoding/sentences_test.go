@@ -0,0 +1,88 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"strings"
+	"testing"
+)
+
+type scanned struct {
+	line    int
+	text    string
+	invalid bool
+}
+
+func scanSentences(t *testing.T, s *Sentences) []scanned {
+	t.Helper()
+
+	var seq []scanned
+	for s.Scan() {
+		seq = append(seq, scanned{line: s.Line(), text: string(s.Sentence().Text), invalid: s.Invalid() != nil})
+	}
+
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return seq
+}
+
+func TestSentencesBlankLines(t *testing.T) {
+	for name, tt := range map[string]struct {
+		json  bool
+		input string
+	}{
+		"text": {false, "\na\n  \t\n\nb\n\n \n"},
+		"json": {true, "\n{\"text\": \"a\"}\n  \t\r\n\n{\"text\": \"b\"}\n\n \n"},
+	} {
+		s := NewSentences(strings.NewReader(tt.input), tt.json)
+
+		expect(t, name, scanSentences(t, s), []scanned{{2, "a", false}, {5, "b", false}})
+		expect(t, name+" offset", s.Offset(), int64(len(tt.input)))
+	}
+}
+
+func TestSentencesInvalid(t *testing.T) {
+	input := `{"text": "a"}
+{"text": "b"
+{"text": ""}
+{"text": "c", "unknown": 1}
+{"text": "d", "isPartOf": "example"}
+{"text": "e"} {}
+{"text": "f"}
+`
+	s := NewSentences(strings.NewReader(input), true)
+
+	expect(t, "sentences", scanSentences(t, s), []scanned{
+		{1, "a", false},
+		{2, "", true},
+		{3, "", true},
+		{4, "c", true},
+		{5, "d", true},
+		{6, "e", true},
+		{7, "f", false},
+	})
+}
+
+func TestSentencesSkip(t *testing.T) {
+	input := "{\"text\": \"a\"}\n\n{\"text\": \"b\"}\n"
+
+	s := NewSentences(strings.NewReader(input), true)
+	if err := s.Skip(int64(len("{\"text\": \"a\"}\n\n"))); err != nil {
+		t.Fatal(err)
+	}
+
+	expect(t, "sentences", scanSentences(t, s), []scanned{{3, "b", false}})
+
+	s = NewSentences(strings.NewReader(input), true)
+	if err := s.Skip(3); err == nil {
+		t.Errorf("skip to the middle of line is accepted")
+	}
+}
@@ -0,0 +1,132 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Scanner of textual format, each line is unique key followed by vector.
+// Key and components of vector are separated by any whitespace. Blank lines
+// and comments (lines starting with #) are skipped. Lines are of any length.
+type Scanner struct {
+	r         *input
+	line      int
+	err       error
+	uniqueKey []byte
+	vector    []float32
+}
+
+func New(r io.Reader) *Scanner {
+	return &Scanner{
+		r: newInput(r),
+	}
+}
+
+func (s *Scanner) Err() error        { return s.err }
+func (s *Scanner) UniqueKey() []byte { return s.uniqueKey }
+func (s *Scanner) Vector() []float32 { return s.vector }
+func (s *Scanner) Offset() int64     { return s.r.pos }
+
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for {
+		line, err := s.r.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+		s.line++
+
+		key, at := token(line, 0)
+		if len(key) == 0 || key[0] == '#' {
+			continue
+		}
+
+		return s.parse(line, key, at)
+	}
+}
+
+func (s *Scanner) parse(line []byte, key []byte, at int) bool {
+	uniqueKey, err := DecodeKey(string(key))
+	if err != nil {
+		s.err = &SyntaxError{Line: s.line, Column: at - len(key) + 1, Err: err}
+		return false
+	}
+
+	vector := make([]float32, 0, len(s.vector))
+	for {
+		tok, end := token(line, at)
+		if len(tok) == 0 {
+			break
+		}
+		at = end
+
+		v, err := strconv.ParseFloat(string(tok), 32)
+		if err != nil {
+			if ne, ok := err.(*strconv.NumError); ok {
+				err = fmt.Errorf("invalid number %q", ne.Num)
+			}
+			s.err = &SyntaxError{Line: s.line, Column: end - len(tok) + 1, Err: err}
+			return false
+		}
+		vector = append(vector, float32(v))
+	}
+
+	if len(vector) == 0 {
+		s.err = &SyntaxError{Line: s.line, Column: at + 1, Err: fmt.Errorf("vector is missing after key %q", key)}
+		return false
+	}
+
+	s.uniqueKey = uniqueKey
+	s.vector = vector
+
+	return true
+}
+
+// lines are skipped without decoding, it keeps line numbers
+func (s *Scanner) Skip(offset int64) error {
+	for s.r.pos < offset {
+		if _, err := s.r.ReadLine(); err != nil {
+			return err
+		}
+		s.line++
+	}
+
+	if s.r.pos != offset {
+		return fmt.Errorf("unable to skip input to %d, it is not a boundary of line", offset)
+	}
+
+	return nil
+}
+
+// returns the token starting from the position and the position after it
+func token(line []byte, at int) ([]byte, int) {
+	for at < len(line) && isSpace(line[at]) {
+		at++
+	}
+
+	start := at
+	for at < len(line) && !isSpace(line[at]) {
+		at++
+	}
+
+	return line[start:at], at
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\v' || c == '\f' || c == '\r'
+}
@@ -0,0 +1,110 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	input := `# comment
+a 1 2
+
+	b	3	4
+c    5   6
+# another comment
+   0x6465 7 8
+`
+	r := New(strings.NewReader(input))
+
+	if err := r.Skip(0); err != nil {
+		t.Fatalf("unable to skip to 0: %v", err)
+	}
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"a", "b", "c", "de"})
+	expect(t, "vectors", vectors, [][]float32{{1, 2}, {3, 4}, {5, 6}, {7, 8}})
+	expect(t, "offset", r.Offset(), int64(len(input)))
+}
+
+func TestTextCRLF(t *testing.T) {
+	r := New(strings.NewReader("a 1 2\r\nb 3 4\r\n"))
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"a", "b"})
+	expect(t, "vectors", vectors, [][]float32{{1, 2}, {3, 4}})
+}
+
+func TestTextLongLine(t *testing.T) {
+	n := 32 * 1024
+	input := "a" + strings.Repeat(" 0.5", n) + "\nb 1\n"
+
+	r := New(strings.NewReader(input))
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"a", "b"})
+	expect(t, "dimension", len(vectors[0]), n)
+	expect(t, "vector", vectors[1], []float32{1})
+}
+
+func TestTextKeyLimit(t *testing.T) {
+	key := strings.Repeat("k", MAX_UNIQUE_KEY)
+
+	r := New(strings.NewReader(key + " 1\n"))
+	keys, _ := readAll(t, r)
+	expect(t, "keys", keys, []string{key})
+
+	r = New(strings.NewReader(key + "k 1\n"))
+	for r.Scan() {
+	}
+	if r.Err() == nil {
+		t.Errorf("key of %d bytes is accepted", MAX_UNIQUE_KEY+1)
+	}
+}
+
+func TestTextSyntaxError(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		input  string
+		line   int
+		column int
+		keys   int
+	}{
+		{"number", "a 1 2\nb 3 x4\n", 2, 5, 1},
+		{"number after tab", "# c\n\n\tb\t3\t\tx\n", 3, 7, 0},
+		{"vector", "a 1\nb   \n", 2, 2, 1},
+		{"hex key", "a 1\n\n  0xzz 1\n", 3, 3, 1},
+		{"key", strings.Repeat("k", MAX_UNIQUE_KEY+1) + " 1\n", 1, 1, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(strings.NewReader(tt.input))
+
+			keys := 0
+			for r.Scan() {
+				keys++
+			}
+
+			var e *SyntaxError
+			if !errors.As(r.Err(), &e) {
+				t.Fatalf("unexpected error %v", r.Err())
+			}
+
+			expect(t, "keys", keys, tt.keys)
+			expect(t, "line", e.Line, tt.line)
+			expect(t, "column", e.Column, tt.column)
+
+			// the scanner is stopped at the error
+			if r.Scan() || r.Err() != e {
+				t.Errorf("scanner continues after error")
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// the upper bound of vector dimension, it protects from corrupted files
+const maxDimension = 1 << 20
+
+// vecs is reader of ANN-benchmark formats (fvecs, bvecs, ivecs). Each vector
+// is little-endian int32 dimension followed by components of given size.
+type vecs struct {
+	r         *input
+	size      int
+	decode    func([]byte, []float32)
+	err       error
+	seq       int
+	uniqueKey []byte
+	vector    []float32
+	buf       []byte
+}
+
+func newVecs(r io.Reader, size int, decode func([]byte, []float32)) *vecs {
+	return &vecs{
+		r:      newInput(r),
+		size:   size,
+		decode: decode,
+	}
+}
+
+func (s *vecs) Err() error              { return s.err }
+func (s *vecs) UniqueKey() []byte       { return s.uniqueKey }
+func (s *vecs) Vector() []float32       { return s.vector }
+func (s *vecs) Offset() int64           { return s.r.pos }
+func (s *vecs) Skip(offset int64) error { return s.skip(offset) }
+
+func (s *vecs) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	var dim [4]byte
+	if err := s.r.ReadFull(dim[:]); err != nil {
+		if err != io.EOF {
+			s.err = fmt.Errorf("vector %d: %w", s.seq, err)
+		}
+		return false
+	}
+
+	d := int(int32(binary.LittleEndian.Uint32(dim[:])))
+	if d <= 0 || d > maxDimension {
+		s.err = fmt.Errorf("vector %d: invalid dimension %d", s.seq, d)
+		return false
+	}
+
+	if cap(s.buf) < d*s.size {
+		s.buf = make([]byte, d*s.size)
+	}
+	s.buf = s.buf[:d*s.size]
+
+	if err := s.r.ReadFull(s.buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		s.err = fmt.Errorf("vector %d: %w", s.seq, err)
+		return false
+	}
+
+	s.vector = make([]float32, d)
+	s.decode(s.buf, s.vector)
+	s.uniqueKey = []byte(strconv.Itoa(s.seq))
+	s.seq++
+
+	return true
+}
+
+// the index of vector is used as key, vectors are scanned to count them
+func (s *vecs) skip(offset int64) error {
+	for s.r.pos < offset && s.Scan() {
+	}
+
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.r.pos != offset {
+		return fmt.Errorf("unable to skip input to %d, it is not a boundary of vector", offset)
+	}
+
+	return nil
+}
+
+func fromFloat32(b []byte, v []float32) {
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+}
+
+func fromUint8(b []byte, v []float32) {
+	for i := range v {
+		v[i] = float32(b[i])
+	}
+}
+
+func fromInt32(b []byte, v []float32) {
+	for i := range v {
+		v[i] = float32(int32(binary.LittleEndian.Uint32(b[i*4:])))
+	}
+}
@@ -0,0 +1,87 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ANN-benchmark vectors, each is dimension followed by components
+func vecsOf(seq ...any) []byte {
+	var buf bytes.Buffer
+	for _, v := range seq {
+		binary.Write(&buf, binary.LittleEndian, int32(binary.Size(v)/sizeOf(v)))
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+func sizeOf(v any) int {
+	switch v.(type) {
+	case []uint8:
+		return 1
+	default:
+		return 4
+	}
+}
+
+func TestVecs(t *testing.T) {
+	for file, input := range map[string][]byte{
+		"a.fvecs": vecsOf([]float32{1, 2, 3}, []float32{4, 5, 6}),
+		"a.bvecs": vecsOf([]uint8{1, 2, 3}, []uint8{4, 5, 6}),
+		"a.ivecs": vecsOf([]int32{1, 2, 3}, []int32{4, 5, 6}),
+	} {
+		r, err := NewReader(bytes.NewReader(input), file, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := r.Skip(0); err != nil {
+			t.Fatalf("%s: unable to skip to 0: %v", file, err)
+		}
+
+		keys, vectors := readAll(t, r)
+		expect(t, file+" keys", keys, []string{"0", "1"})
+		expect(t, file+" vectors", vectors, [][]float32{{1, 2, 3}, {4, 5, 6}})
+	}
+}
+
+func TestVecsResume(t *testing.T) {
+	input := vecsOf([]float32{1, 2}, []float32{3, 4}, []float32{5, 6})
+
+	r, _ := NewReader(bytes.NewReader(input), "a.fvecs", Options{})
+	r.Scan()
+	offset := r.Offset()
+
+	r, _ = NewReader(bytes.NewReader(input), "a.fvecs", Options{})
+	if err := r.Skip(offset); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, vectors := readAll(t, r)
+	expect(t, "keys", keys, []string{"1", "2"})
+	expect(t, "vectors", vectors, [][]float32{{3, 4}, {5, 6}})
+}
+
+func TestVecsInvalid(t *testing.T) {
+	for name, input := range map[string][]byte{
+		"truncated": vecsOf([]float32{1, 2, 3})[:12],
+		"dimension": {0, 0, 0, 0},
+		"negative":  {0xff, 0xff, 0xff, 0xff},
+	} {
+		r, _ := NewReader(bytes.NewReader(input), "a.fvecs", Options{})
+		for r.Scan() {
+		}
+		if r.Err() == nil {
+			t.Errorf("%s vector is accepted", name)
+		}
+	}
+}
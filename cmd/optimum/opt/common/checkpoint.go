@@ -0,0 +1,136 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fogfish/curie"
+)
+
+func AboutCheckpoint(kind string) string {
+	return fmt.Sprintf(`
+The upload persists checkpoint file next to the dataset (e.g. data.txt.checkpoint)
+after each chunk is acknowledged by the server. Use --resume flag to continue
+the interrupted upload, the part of dataset acknowledged earlier is skipped.
+The checkpoint is removed once the upload is completed.
+
+  optimum %s upload -n example --resume path/to/data.txt
+`, kind)
+}
+
+// Checkpoint is the position of dataset acknowledged by the server
+type Checkpoint struct {
+	Cask   curie.IRI `json:"cask"`
+	Source string    `json:"source"`
+	Size   int64     `json:"size"`
+	Offset int64     `json:"offset"`
+
+	// size of the reject file at the offset
+	Rejected int64 `json:"rejected,omitempty"`
+
+	file string
+}
+
+// NewCheckpoint creates the checkpoint of dataset upload into the cask. The
+// existing checkpoint is loaded if the upload is resumed, otherwise upload
+// starts from the beginning of dataset.
+//
+// Standard input is not checkpointed, it cannot be resumed.
+func NewCheckpoint(cask curie.IRI, source string, size int64, resume bool) (*Checkpoint, error) {
+	if source == STDIN {
+		if resume {
+			return nil, errors.New("standard input cannot be resumed, upload without --resume")
+		}
+		return &Checkpoint{Cask: cask, Source: source, Size: size}, nil
+	}
+
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return nil, err
+	}
+
+	ckpt := &Checkpoint{
+		Cask:   cask,
+		Source: abs,
+		Size:   size,
+		file:   source + ".checkpoint",
+	}
+
+	if !resume {
+		return ckpt, nil
+	}
+
+	b, err := os.ReadFile(ckpt.file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return ckpt, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var saved Checkpoint
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", ckpt.file, err)
+	}
+
+	if saved.Cask != ckpt.Cask || saved.Source != ckpt.Source || saved.Size != ckpt.Size {
+		return nil, fmt.Errorf("checkpoint %s belongs to other upload (%s of %s), remove it or upload without --resume", ckpt.file, saved.Source, saved.Cask)
+	}
+
+	// offset of compressed dataset refers to decompressed content, it might
+	// exceed the size of file
+	if saved.Offset < 0 {
+		return nil, fmt.Errorf("invalid checkpoint %s: offset %d is out of dataset", ckpt.file, saved.Offset)
+	}
+
+	ckpt.Offset = saved.Offset
+	ckpt.Rejected = saved.Rejected
+	return ckpt, nil
+}
+
+// Save the offset acknowledged by the server together with the size of reject
+// file at this offset (see Rejects.Size), the file is replaced atomically.
+// The checkpoint only moves forward.
+func (ckpt *Checkpoint) Save(offset int64, rejected int64) error {
+	if ckpt.file == "" || offset <= ckpt.Offset {
+		return nil
+	}
+	ckpt.Offset = offset
+	ckpt.Rejected = rejected
+
+	b, err := json.Marshal(ckpt)
+	if err != nil {
+		return err
+	}
+
+	tmp := ckpt.file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, ckpt.file)
+}
+
+// Remove the checkpoint once the upload is completed
+func (ckpt *Checkpoint) Remove() error {
+	if ckpt.file == "" {
+		return nil
+	}
+
+	err := os.Remove(ckpt.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -11,11 +11,9 @@ package common
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/fogfish/curie"
 	"github.com/kshard/optimum"
-	"github.com/schollz/progressbar/v3"
 )
 
 func AboutCommit(kind, extension string) string {
@@ -26,33 +24,16 @@ upload before dataset is available to reads.
 `, kind, extension)
 }
 
-// List all data structures of given type
-func Commit(api *optimum.Client, id curie.IRI) (err error) {
+// Commit uploaded dataset
+func Commit(api *optimum.Client, id curie.IRI, w Wait, out *Output) (err error) {
 	receipt, err := api.Commit(context.Background(), id)
 	if err != nil {
 		return err
 	}
 
-	bar := progressbar.NewOptions(-1,
-		progressbar.OptionSpinnerType(14),
-		progressbar.OptionSetDescription(
-			fmt.Sprintf("%s (vsn %s) | %s ...", curie.Reference(id), receipt.Version, "COMMITTING"),
-		),
+	return wait(api,
+		Job{ID: id, Version: receipt.Version, Job: receipt.Job},
+		fmt.Sprintf("%s (vsn %s) | %s ...", curie.Reference(id), receipt.Version, "COMMITTING"),
+		w, out,
 	)
-
-	return spinner(bar, func() error {
-		for {
-			time.Sleep(IDLE_TIME)
-
-			status, err := api.Status(context.Background(), receipt.Job)
-			if err != nil {
-				return err
-			}
-
-			bar.Describe(fmt.Sprintf("%s (vsn %s) | %s ...", curie.Reference(id), receipt.Version, status.Status))
-			if status.Status == "SUCCEEDED" || status.Status == "FAILED" {
-				return nil
-			}
-		}
-	})
 }
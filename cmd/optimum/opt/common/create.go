@@ -9,15 +9,16 @@
 package common
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"time"
+	"strings"
 
 	"github.com/fogfish/curie"
 	"github.com/kshard/optimum"
-	"github.com/schollz/progressbar/v3"
 )
 
 func AboutCreate(kind, extension string) string {
@@ -28,45 +29,73 @@ parameters causes usage of default params.
 `, kind, extension)
 }
 
-func Create(api *optimum.Client, id curie.IRI, fopts string) (err error) {
-	opts := map[string]any{}
+// Config of data structure instance
+type Config interface {
+	Validate() error
+}
+
+// Create new instance of data structure, the config is read from fopts file
+// into the typed config and validated before the request.
+func Create(api *optimum.Client, id curie.IRI, fopts string, opts Config, w Wait, out *Output) (err error) {
+	if err := ReadConfig(fopts, opts); err != nil {
+		return err
+	}
+
+	receipt, err := api.Create(context.Background(), id, opts)
+	if err != nil {
+		return err
+	}
+
+	about, _ := json.Marshal(opts)
+
+	return wait(api,
+		Job{ID: id, Version: receipt.Version, Job: receipt.Job},
+		fmt.Sprintf("%s (vsn %s) | %s ... opts: %s", curie.Reference(id), receipt.Version, "CREATING", about),
+		w, out,
+	)
+}
 
+// ReadConfig decodes json config file into typed config and validates it.
+// Empty file name keeps the config as is.
+func ReadConfig(fopts string, opts Config) error {
 	if fopts != "" {
 		b, err := os.ReadFile(fopts)
 		if err != nil {
 			return err
 		}
 
-		if err := json.Unmarshal(b, &opts); err != nil {
-			return err
+		codec := json.NewDecoder(bytes.NewReader(b))
+		codec.DisallowUnknownFields()
+		if err := codec.Decode(opts); err != nil {
+			var syntax *json.SyntaxError
+			if errors.As(err, &syntax) {
+				line, col := position(b, syntax.Offset)
+				return fmt.Errorf("invalid config %s:%d:%d: %w", fopts, line, col, err)
+			}
+			return fmt.Errorf("invalid config %s: %w", fopts, err)
 		}
 	}
 
-	receipt, err := api.Create(context.Background(), id, opts)
-	if err != nil {
+	if err := opts.Validate(); err != nil {
+		var e *optimum.ConfigError
+		if errors.As(err, &e) {
+			return fmt.Errorf("invalid config %s:\n  - %s", fopts, strings.Join(e.Violations, "\n  - "))
+		}
 		return err
 	}
 
-	bar := progressbar.NewOptions(-1,
-		progressbar.OptionSpinnerType(14),
-		progressbar.OptionSetDescription(
-			fmt.Sprintf("%s (vsn %s) | %s ... opts: %+v", curie.Reference(id), receipt.Version, "CREATING", opts),
-		),
-	)
-
-	return spinner(bar, func() error {
-		for {
-			time.Sleep(IDLE_TIME)
-
-			status, err := api.Status(context.Background(), receipt.Job)
-			if err != nil {
-				return err
-			}
+	return nil
+}
 
-			bar.Describe(fmt.Sprintf("%s (vsn %s) | %s ...", curie.Reference(id), receipt.Version, status.Status))
-			if status.Status == "SUCCEEDED" || status.Status == "FAILED" {
-				return nil
-			}
+// line and column of the offset within the file
+func position(b []byte, offset int64) (int, int) {
+	line, col := 1, 1
+	for _, c := range b[:min(int(offset), len(b))] {
+		if c == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
 		}
-	})
+	}
+	return line, col
 }
@@ -0,0 +1,100 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fogfish/curie"
+	"github.com/kshard/optimum"
+)
+
+func AboutDescribe(kind, extension string) string {
+	return fmt.Sprintf(`
+Describe "%s" data structure instance. It reports the instance STATUS, active
+VERSION, PENDING version if any, UPDATED AT timestamp, full configuration
+PARAMS the instance is built with and the status of the latest JOB.
+%s
+`, kind, extension)
+}
+
+// Description of data structure instance
+type Description struct {
+	*optimum.Instance
+	Params    Config             `json:"params,omitempty"`
+	JobStatus *optimum.JobStatus `json:"jobStatus,omitempty"`
+}
+
+// Describe the instance of data structure, the config of instance is decoded
+// into the typed config.
+func Describe(api *optimum.Client, id curie.IRI, opts Config, out *Output) (err error) {
+	inst, err := api.Cask(context.Background(), id)
+	if err != nil {
+		return err
+	}
+
+	if err := inst.DecodeOpts(opts); err != nil {
+		return fmt.Errorf("unable to decode config of %s: %w", inst.ID, err)
+	}
+
+	desc := Description{Instance: inst, Params: opts}
+
+	// the job status is auxiliary, the job might be expired (e.g. 404)
+	if inst.Job != "" {
+		desc.JobStatus, err = api.Status(context.Background(), inst.Job)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "==> status of job %s is not available: %s\n", inst.Job, err)
+			desc.JobStatus = nil
+		}
+	}
+
+	if !out.Table() {
+		return out.Write(desc)
+	}
+
+	return describe(desc)
+}
+
+func describe(desc Description) error {
+	fmt.Printf("%-12s %s\n", "NAME", curie.Reference(desc.ID))
+	fmt.Printf("%-12s %s\n", "STATUS", desc.Status)
+	fmt.Printf("%-12s %s\n", "VERSION", desc.Version)
+	fmt.Printf("%-12s %s\n", "PENDING", desc.Pending)
+	fmt.Printf("%-12s %s\n", "UPDATED AT", desc.Updated.Format(time.DateTime))
+
+	b, err := json.MarshalIndent(desc.Params, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-12s\n  %s\n", "PARAMS", strings.TrimSpace(string(b)))
+
+	if desc.Job == "" {
+		return nil
+	}
+
+	fmt.Printf("%-12s %s\n", "JOB", desc.Job)
+	if desc.JobStatus == nil {
+		fmt.Printf("  %-10s %s\n", "STATUS", "unknown")
+		return nil
+	}
+
+	fmt.Printf("  %-10s %s\n", "STATUS", desc.JobStatus.Status)
+	fmt.Printf("  %-10s %s\n", "REASON", desc.JobStatus.Reason)
+	fmt.Printf("  %-10s %s\n", "CREATED", desc.JobStatus.Created)
+	fmt.Printf("  %-10s %s\n", "STARTED", desc.JobStatus.Started)
+	fmt.Printf("  %-10s %s\n", "STOPPED", desc.JobStatus.Stopped)
+
+	return nil
+}
@@ -0,0 +1,260 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kshard/optimum/surface"
+)
+
+// Eval accumulates quality and latency of queries against the ground truth
+type Eval struct {
+	k       int
+	queries int
+	recall  float64
+	rr      float64
+	ndcg    float64
+	latency []time.Duration
+}
+
+// NewEval creates the accumulator of metrics at k nearest neighbors
+func NewEval(k int) *Eval {
+	return &Eval{k: k}
+}
+
+// Add evaluates keys of retrieved neighbors against keys of true nearest
+// neighbors, both are ordered by distance. Neighbors are relevant if they are
+// in top k of the ground truth, the reciprocal rank refers the nearest one.
+func (e *Eval) Add(hits []string, truth []string, latency time.Duration) {
+	e.queries++
+	e.latency = append(e.latency, latency)
+
+	if len(truth) > e.k {
+		truth = truth[:e.k]
+	}
+	if len(hits) > e.k {
+		hits = hits[:e.k]
+	}
+	if len(truth) == 0 {
+		return
+	}
+
+	relevant := make(map[string]struct{}, len(truth))
+	for _, key := range truth {
+		relevant[key] = struct{}{}
+	}
+
+	found, rr, dcg, idcg := 0, 0.0, 0.0, 0.0
+	for i, key := range hits {
+		if _, has := relevant[key]; has {
+			delete(relevant, key)
+			found++
+			dcg += 1 / math.Log2(float64(i+2))
+		}
+
+		if key == truth[0] && rr == 0 {
+			rr = 1 / float64(i+1)
+		}
+	}
+
+	for i := range truth {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+
+	e.recall += float64(found) / float64(len(truth))
+	e.rr += rr
+	e.ndcg += dcg / idcg
+}
+
+// Report of evaluation
+type EvalReport struct {
+	K       int         `json:"k"`
+	Queries int         `json:"queries"`
+	Recall  float64     `json:"recall"`
+	MRR     float64     `json:"mrr"`
+	NDCG    float64     `json:"ndcg"`
+	Latency LatencyStat `json:"latency"`
+}
+
+// Latency percentiles of queries
+type LatencyStat struct {
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// Report metrics averaged over queries
+func (e *Eval) Report() EvalReport {
+	report := EvalReport{K: e.k, Queries: e.queries}
+	if e.queries == 0 {
+		return report
+	}
+
+	n := float64(e.queries)
+	report.Recall = e.recall / n
+	report.MRR = e.rr / n
+	report.NDCG = e.ndcg / n
+
+	seq := append([]time.Duration{}, e.latency...)
+	sort.Slice(seq, func(i, j int) bool { return seq[i] < seq[j] })
+
+	var total time.Duration
+	for _, t := range seq {
+		total += t
+	}
+
+	report.Latency = LatencyStat{
+		Mean: total / time.Duration(len(seq)),
+		P50:  percentile(seq, 0.50),
+		P90:  percentile(seq, 0.90),
+		P99:  percentile(seq, 0.99),
+		Max:  seq[len(seq)-1],
+	}
+
+	return report
+}
+
+// nearest-rank percentile of sorted sequence
+func percentile(seq []time.Duration, p float64) time.Duration {
+	at := int(math.Ceil(p*float64(len(seq)))) - 1
+	if at < 0 {
+		at = 0
+	}
+	return seq[at]
+}
+
+//------------------------------------------------------------------------------
+
+// BruteForce computes exact k nearest neighbors of queries by scanning the
+// dataset, it is the ground truth for evaluation of approximate search.
+type BruteForce struct {
+	k        int
+	distance func(a, b []float32) float32
+	queries  [][]float32
+	nearest  [][]neighbor
+	keys     []string
+	vectors  [][]float32
+}
+
+type neighbor struct {
+	key      string
+	distance float32
+}
+
+// the number of vectors scanned concurrently against all queries
+const bruteForceChunk = 1024
+
+// NewBruteForce creates the ground truth for queries, the kind of distance is
+// either surface.Cosine or surface.Euclidean.
+func NewBruteForce(kind string, k int, queries [][]float32) *BruteForce {
+	distance := cosine
+	if kind == surface.Euclidean {
+		distance = euclidean
+	}
+
+	return &BruteForce{
+		k:        k,
+		distance: distance,
+		queries:  queries,
+		nearest:  make([][]neighbor, len(queries)),
+	}
+}
+
+// Add vector of the dataset
+func (bf *BruteForce) Add(key string, vector []float32) {
+	bf.keys = append(bf.keys, key)
+	bf.vectors = append(bf.vectors, vector)
+	if len(bf.keys) == bruteForceChunk {
+		bf.flush()
+	}
+}
+
+// Truth returns keys of nearest neighbors of the query, ordered by distance
+func (bf *BruteForce) Truth(query int) []string {
+	bf.flush()
+
+	keys := make([]string, len(bf.nearest[query]))
+	for i, n := range bf.nearest[query] {
+		keys[i] = n.key
+	}
+	return keys
+}
+
+// queries are partitioned across CPUs, each compares the chunk of dataset
+func (bf *BruteForce) flush() {
+	if len(bf.keys) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	workers := runtime.NumCPU()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for q := w; q < len(bf.queries); q += workers {
+				for i, v := range bf.vectors {
+					bf.insert(q, neighbor{key: bf.keys[i], distance: bf.distance(bf.queries[q], v)})
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	bf.keys, bf.vectors = bf.keys[:0], bf.vectors[:0]
+}
+
+// nearest neighbors of the query are kept sorted, the farthest is dropped
+func (bf *BruteForce) insert(q int, n neighbor) {
+	seq := bf.nearest[q]
+	if len(seq) == bf.k && n.distance >= seq[len(seq)-1].distance {
+		return
+	}
+
+	at := sort.Search(len(seq), func(i int) bool { return seq[i].distance > n.distance })
+	if len(seq) < bf.k {
+		seq = append(seq, neighbor{})
+	}
+	copy(seq[at+1:], seq[at:])
+	seq[at] = n
+
+	bf.nearest[q] = seq
+}
+
+func cosine(a, b []float32) float32 {
+	var ab, aa, bb float64
+	for i := range a {
+		ab += float64(a[i]) * float64(b[i])
+		aa += float64(a[i]) * float64(a[i])
+		bb += float64(b[i]) * float64(b[i])
+	}
+
+	if aa == 0 || bb == 0 {
+		return 1
+	}
+
+	return float32(1 - ab/math.Sqrt(aa*bb))
+}
+
+// squared euclidean distance, it keeps the order of neighbors
+func euclidean(a, b []float32) float32 {
+	var d float64
+	for i := range a {
+		x := float64(a[i]) - float64(b[i])
+		d += x * x
+	}
+	return float32(d)
+}
@@ -0,0 +1,132 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kshard/optimum"
+	"github.com/schollz/progressbar/v3"
+)
+
+// The file name used for standard input
+const STDIN = "-"
+
+func AboutInput() string {
+	return `
+Use "-" as file name to read the dataset from standard input. Compressed files
+(.gz and .zst) are decompressed transparently, the compression is also detected
+from the content, which is useful for standard input.
+`
+}
+
+// Input of the command, either file or standard input. Compressed input is
+// decompressed transparently.
+type Input struct {
+	*bufio.Reader
+
+	// Name of the file without compression extension, "-" for standard input
+	File string
+
+	// Size of the file (bytes), -1 if the size is unknown
+	Size int64
+
+	fd  *os.File
+	dec io.Closer
+}
+
+// OpenInput opens the file or standard input ("-"). The progress is shown
+// if the label is defined, the spinner is used if the size is unknown.
+func OpenInput(file string, label string) (*Input, error) {
+	in := &Input{File: file, Size: -1}
+
+	var r io.Reader = os.Stdin
+	if file != STDIN {
+		fd, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		in.fd = fd
+		r = fd
+
+		fi, err := fd.Stat()
+		if err != nil {
+			in.Close()
+			return nil, err
+		}
+		if fi.Mode().IsRegular() {
+			in.Size = fi.Size()
+		}
+	}
+
+	if label != "" {
+		r = io.TeeReader(r, progressbar.DefaultBytes(in.Size, label))
+	}
+
+	buf := bufio.NewReaderSize(r, 64*1024)
+	compression := in.compression(buf)
+	if compression == optimum.Identity {
+		in.Reader = buf
+		return in, nil
+	}
+
+	dec, err := compression.NewReader(buf)
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+	in.dec = dec
+	in.Reader = bufio.NewReaderSize(dec, 64*1024)
+
+	return in, nil
+}
+
+var (
+	magicGzip = []byte{0x1f, 0x8b}
+	magicZstd = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compression is derived from extension or magic bytes
+func (in *Input) compression(r *bufio.Reader) optimum.Compression {
+	switch strings.ToLower(filepath.Ext(in.File)) {
+	case ".gz", ".gzip":
+		in.File = strings.TrimSuffix(in.File, filepath.Ext(in.File))
+		return optimum.Gzip
+	case ".zst", ".zstd":
+		in.File = strings.TrimSuffix(in.File, filepath.Ext(in.File))
+		return optimum.Zstd
+	}
+
+	magic, _ := r.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, magicGzip):
+		return optimum.Gzip
+	case bytes.HasPrefix(magic, magicZstd):
+		return optimum.Zstd
+	default:
+		return optimum.Identity
+	}
+}
+
+func (in *Input) Close() error {
+	if in.dec != nil {
+		in.dec.Close()
+	}
+
+	if in.fd != nil {
+		return in.fd.Close()
+	}
+
+	return nil
+}
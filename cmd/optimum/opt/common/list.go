@@ -10,7 +10,9 @@ package common
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fogfish/curie"
@@ -35,21 +37,73 @@ The STATUS reflect both status of the instance and ongoing update operation:
 - "PENDING" the instance is pending updates, the VERSION is available online. 
 - "ACTIVE" the instance is active, all past updates successfully completed.
 - "FAILED" PENDING update is failed, the VERSION is available online.
+
+Use --status, --prefix and --limit flags to narrow down the listing:
+
+  optimum %[1]s list -u $HOST --status active --prefix example --limit 10
 %s
 `, kind, extension)
 }
 
-// List all data structures of given type
-func List(api *optimum.Client, kind string) (err error) {
-	seq, err := api.Casks(context.Background(), kind)
-	if err != nil {
-		return err
+// Filter of listed data structure instances
+type Filter struct {
+	// Only instances with the status (e.g. ACTIVE), case insensitive
+	Status string
+
+	// Only instances which name starts with the prefix
+	Prefix string
+
+	// Maximum number of listed instances, zero value means no limit
+	Limit int
+}
+
+func (f Filter) match(inst optimum.Instance) bool {
+	return (f.Status == "" || strings.EqualFold(f.Status, inst.Status)) &&
+		strings.HasPrefix(curie.Reference(inst.ID), f.Prefix)
+}
+
+// List all data structures of given type, the config of each instance is
+// decoded into the typed config produced by opts.
+func List(api *optimum.Client, kind string, filter Filter, opts func() Config, out *Output) (err error) {
+	if out.Table() {
+		fmt.Printf("%-10s\t%-16s %-19s | %-11s %-16s | %s\n", "NAME", "VERSION", "UPDATED AT", "STATUS", "PENDING", "PARAMS")
 	}
 
-	fmt.Printf("%-10s\t%-16s %-19s | %-11s %-16s |\n", "NAME", "VERSION", "UPDATED AT", "STATUS", "PENDING")
-	for _, x := range seq.Items {
-		fmt.Printf("%-10s\t%-16s %-19s | %-11s %-16s |\n", curie.Reference(x.ID), x.Version, x.Updated.Format(time.DateTime), x.Status, x.Pending)
+	n := 0
+	for x, err := range api.CasksSeq(context.Background(), kind) {
+		if err != nil {
+			return err
+		}
+
+		if !filter.match(x) {
+			continue
+		}
+
+		if out.Table() {
+			fmt.Printf("%-10s\t%-16s %-19s | %-11s %-16s | %s\n", curie.Reference(x.ID), x.Version, x.Updated.Format(time.DateTime), x.Status, x.Pending, params(x, opts()))
+		} else if err := out.Write(x); err != nil {
+			return err
+		}
+
+		if n++; filter.Limit > 0 && n >= filter.Limit {
+			break
+		}
 	}
 
 	return nil
 }
+
+// compact representation of instance config, it fallbacks to raw config if
+// it is not decodable.
+func params(inst optimum.Instance, opts Config) string {
+	if err := inst.DecodeOpts(opts); err != nil {
+		return inst.Opts
+	}
+
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return inst.Opts
+	}
+
+	return string(b)
+}
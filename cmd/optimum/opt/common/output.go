@@ -0,0 +1,577 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by commands
+const (
+	FORMAT_TABLE = "table"
+	FORMAT_JSON  = "json"
+	FORMAT_JSONL = "jsonl"
+	FORMAT_YAML  = "yaml"
+	FORMAT_CSV   = "csv"
+)
+
+// Output renders records in the structured format. The field names are
+// derived from json tags of the record types (e.g. optimum.Instance).
+//
+// The "table" format is a human readable output, commands print it on
+// their own. Other formats are streamed record by record:
+//   - "json" is an array of records;
+//   - "jsonl" is one record per line;
+//   - "yaml" is a sequence of records;
+//   - "csv" is one row per record, nested objects are flattened using dot
+//     notation for column names, the first nested list of objects (e.g. hits)
+//     is unfolded into one row per element. Columns are derived from the
+//     record type (or the first record if it is not a struct), omitted fields
+//     are empty cells, fields out of columns fail the output.
+type Output struct {
+	format string
+	w      io.Writer
+	n      int
+	csv    *csv.Writer
+	schema *schema
+}
+
+// NewOutput creates output of given format
+func NewOutput(format string, w io.Writer) (*Output, error) {
+	switch format {
+	case FORMAT_TABLE, FORMAT_JSON, FORMAT_JSONL, FORMAT_YAML:
+		return &Output{format: format, w: w}, nil
+	case FORMAT_CSV:
+		return &Output{format: format, w: w, csv: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("output format %q is not supported, use one of table, json, jsonl, yaml or csv", format)
+	}
+}
+
+// Table is true if output is human readable table
+func (out *Output) Table() bool { return out.format == FORMAT_TABLE }
+
+// Progress is a destination for progress reporting, it is kept apart from
+// structured output.
+func (out *Output) Progress() io.Writer {
+	if out.Table() {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// Write record to output
+func (out *Output) Write(v any) error {
+	defer func() { out.n++ }()
+
+	switch out.format {
+	case FORMAT_JSON:
+		b, err := json.MarshalIndent(v, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		sep := ","
+		if out.n == 0 {
+			sep = "["
+		}
+		_, err = fmt.Fprintf(out.w, "%s\n  %s", sep, b)
+		return err
+	case FORMAT_JSONL:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out.w, "%s\n", b)
+		return err
+	case FORMAT_YAML:
+		return out.writeYAML(v)
+	case FORMAT_CSV:
+		return out.writeCSV(v)
+	default:
+		return fmt.Errorf("output format %q does not support records", out.format)
+	}
+}
+
+// Close output, it completes the document
+func (out *Output) Close() error {
+	switch out.format {
+	case FORMAT_JSON:
+		if out.n == 0 {
+			_, err := fmt.Fprintln(out.w, "[]")
+			return err
+		}
+		_, err := fmt.Fprintln(out.w, "\n]")
+		return err
+	case FORMAT_YAML:
+		if out.n == 0 {
+			_, err := fmt.Fprintln(out.w, "[]")
+			return err
+		}
+	case FORMAT_CSV:
+		out.csv.Flush()
+		return out.csv.Error()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// object preserving the order of fields
+type object []field
+
+type field struct {
+	key string
+	val any
+}
+
+// decodes value into generic representation preserving the order of fields
+func ordered(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := json.NewDecoder(bytes.NewReader(b))
+	codec.UseNumber()
+
+	return decode(codec)
+}
+
+func decode(codec *json.Decoder) (any, error) {
+	t, err := codec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		obj := object{}
+		for codec.More() {
+			k, err := codec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decode(codec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, field{key: k.(string), val: v})
+		}
+		if _, err := codec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case json.Delim('['):
+		seq := []any{}
+		for codec.More() {
+			v, err := decode(codec)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+		}
+		if _, err := codec.Token(); err != nil {
+			return nil, err
+		}
+		return seq, nil
+	default:
+		return t, nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// yaml of the record is derived from its json, json is a subset of yaml.
+// The record is emitted as an item of the sequence using block style.
+func (out *Output) writeYAML(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: doc.Content}
+	blockStyle(seq)
+
+	enc := yaml.NewEncoder(out.w)
+	enc.SetIndent(2)
+	if err := enc.Encode(seq); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// resets flow style of json, scalars are quoted only if required
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, x := range node.Content {
+		blockStyle(x)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (out *Output) writeCSV(v any) error {
+	obj, err := ordered(v)
+	if err != nil {
+		return err
+	}
+
+	if out.schema == nil {
+		out.schema = schemaOf(reflect.ValueOf(v), obj)
+		if err := out.csv.Write(out.schema.header()); err != nil {
+			return err
+		}
+	}
+
+	// the cell out of header is never dropped silently
+	if path := out.schema.unknown(obj, nil, false); path != nil {
+		return fmt.Errorf("csv column %q is not defined by header, use json output", strings.Join(path, "."))
+	}
+
+	for _, rec := range out.schema.rows(obj) {
+		if err := out.csv.Write(rec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// schema of csv is derived from the type of record, columns do not depend on
+// omitted fields (omitempty). The first list of structs (e.g. hits) is
+// unfolded into one row per element.
+type schema struct {
+	columns [][]string
+	unfold  []string
+	nested  [][]string
+}
+
+var (
+	typeJSONMarshaler = reflect.TypeFor[json.Marshaler]()
+	typeTextMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// schema of the record, the type of struct defines columns, the first record
+// defines columns of other types (e.g. map).
+func schemaOf(v reflect.Value, obj any) *schema {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+
+	s := &schema{}
+	if v.IsValid() && v.Kind() == reflect.Struct && !isLeaf(v.Type()) {
+		s.walk(v.Type(), v, nil, false)
+		return s
+	}
+
+	if x, ok := obj.(object); ok {
+		s.walkObject(x, nil, false)
+		return s
+	}
+
+	s.column(nil, false)
+	return s
+}
+
+// walks fields of the type, the value refines interfaces (e.g. config)
+func (s *schema) walk(t reflect.Type, v reflect.Value, path []string, nested bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if v.IsValid() {
+			v = v.Elem()
+		}
+	}
+
+	if t.Kind() == reflect.Interface {
+		if v.IsValid() && !v.IsNil() {
+			s.walk(v.Elem().Type(), v.Elem(), path, nested)
+			return
+		}
+		s.column(path, nested)
+		return
+	}
+
+	switch {
+	case isLeaf(t):
+		s.column(path, nested)
+	case t.Kind() == reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" || (!f.IsExported() && !f.Anonymous) {
+				continue
+			}
+
+			var fv reflect.Value
+			if v.IsValid() {
+				fv = v.Field(i)
+			}
+
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && !isLeaf(ft) {
+				s.walk(f.Type, fv, path, nested)
+				continue
+			}
+
+			if name == "" {
+				name = f.Name
+			}
+			s.walk(f.Type, fv, append(append([]string{}, path...), name), nested)
+		}
+	case t.Kind() == reflect.Slice && !nested && s.unfold == nil && isStruct(t.Elem()):
+		s.unfold = path
+		s.walk(t.Elem(), reflect.Value{}, nil, true)
+	default:
+		s.column(path, nested)
+	}
+}
+
+// walks fields of the generic representation, the order of fields is kept
+func (s *schema) walkObject(obj object, path []string, nested bool) {
+	for _, f := range obj {
+		at := append(append([]string{}, path...), f.key)
+		switch x := f.val.(type) {
+		case object:
+			if len(x) > 0 {
+				s.walkObject(x, at, nested)
+				continue
+			}
+		case []any:
+			if !nested && s.unfold == nil && isListOfObjects(x) {
+				s.unfold = at
+				s.walkObject(x[0].(object), nil, true)
+				continue
+			}
+		}
+		s.column(at, nested)
+	}
+}
+
+func (s *schema) column(path []string, nested bool) {
+	if nested {
+		s.nested = append(s.nested, path)
+	} else {
+		s.columns = append(s.columns, path)
+	}
+}
+
+func (s *schema) header() []string {
+	header := make([]string, 0, len(s.columns)+len(s.nested))
+	for _, path := range s.columns {
+		header = append(header, columnName(path))
+	}
+	for _, path := range s.nested {
+		header = append(header, columnName(append(append([]string{}, s.unfold...), path...)))
+	}
+	return header
+}
+
+// the record of scalar type is a single column
+func columnName(path []string) string {
+	if len(path) == 0 {
+		return "value"
+	}
+	return strings.Join(path, ".")
+}
+
+// rows of the record, the record without elements of unfolded list is one row
+func (s *schema) rows(obj any) [][]string {
+	row := make([]string, 0, len(s.columns)+len(s.nested))
+	for _, path := range s.columns {
+		row = append(row, csvCell(lookup(obj, path)))
+	}
+
+	seq, _ := lookup(obj, s.unfold).([]any)
+	if s.unfold == nil || len(seq) == 0 {
+		for range s.nested {
+			row = append(row, "")
+		}
+		return [][]string{row}
+	}
+
+	rows := make([][]string, 0, len(seq))
+	for _, e := range seq {
+		sub := append([]string{}, row...)
+		for _, path := range s.nested {
+			sub = append(sub, csvCell(lookup(e, path)))
+		}
+		rows = append(rows, sub)
+	}
+
+	return rows
+}
+
+// path to the first value of the record that is not covered by columns
+func (s *schema) unknown(v any, path []string, nested bool) []string {
+	if v == nil || s.has(path, nested) {
+		return nil
+	}
+
+	if !nested && s.unfold != nil && slices.Equal(path, s.unfold) {
+		seq, _ := v.([]any)
+		for _, e := range seq {
+			if at := s.unknown(e, nil, true); at != nil {
+				return append(append([]string{}, path...), at...)
+			}
+		}
+		return nil
+	}
+
+	obj, ok := v.(object)
+	if !ok {
+		return append([]string{}, path...)
+	}
+
+	for _, f := range obj {
+		if at := s.unknown(f.val, append(append([]string{}, path...), f.key), nested); at != nil {
+			return at
+		}
+	}
+	return nil
+}
+
+func (s *schema) has(path []string, nested bool) bool {
+	columns := s.columns
+	if nested {
+		columns = s.nested
+	}
+	for _, c := range columns {
+		if slices.Equal(c, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// value of the field at the path, nil if it is omitted
+func lookup(v any, path []string) any {
+	for _, key := range path {
+		obj, ok := v.(object)
+		if !ok {
+			return nil
+		}
+
+		v = nil
+		for _, f := range obj {
+			if f.key == key {
+				v = f.val
+				break
+			}
+		}
+	}
+	return v
+}
+
+// types with custom encoding and non-struct types are single column
+func isLeaf(t reflect.Type) bool {
+	if t.Implements(typeJSONMarshaler) || reflect.PointerTo(t).Implements(typeJSONMarshaler) ||
+		t.Implements(typeTextMarshaler) || reflect.PointerTo(t).Implements(typeTextMarshaler) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Interface, reflect.Slice:
+		return false
+	default:
+		return true
+	}
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isLeaf(t)
+}
+
+func isListOfObjects(seq []any) bool {
+	if len(seq) == 0 {
+		return false
+	}
+	for _, e := range seq {
+		if _, ok := e.(object); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func csvCell(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case bool:
+		return strconv.FormatBool(x)
+	default:
+		var sb strings.Builder
+		writeJSON(&sb, x)
+		return sb.String()
+	}
+}
+
+// compact json of generic representation
+func writeJSON(sb *strings.Builder, v any) {
+	switch x := v.(type) {
+	case object:
+		sb.WriteString("{")
+		for i, f := range x {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(strconv.Quote(f.key) + ":")
+			writeJSON(sb, f.val)
+		}
+		sb.WriteString("}")
+	case []any:
+		sb.WriteString("[")
+		for i, e := range x {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			writeJSON(sb, e)
+		}
+		sb.WriteString("]")
+	case string:
+		b, _ := json.Marshal(x)
+		sb.Write(b)
+	case json.Number:
+		sb.WriteString(x.String())
+	case bool:
+		sb.WriteString(strconv.FormatBool(x))
+	default:
+		sb.WriteString("null")
+	}
+}
@@ -0,0 +1,168 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kshard/optimum"
+	"github.com/kshard/optimum/surface"
+)
+
+type queryResult struct {
+	Query string `json:"query"`
+	*surface.Result
+}
+
+func TestOutputCSVColumnsOfType(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FORMAT_CSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := optimum.Source{Cask: "example", Version: "v1", Size: 2}
+	seq := []queryResult{
+		{Query: "a", Result: &surface.Result{Source: source}},
+		{Query: "b", Result: &surface.Result{
+			Took:   time.Millisecond,
+			Source: source,
+			Hits: []surface.Hit{
+				{UniqueKey: []byte("k1"), Rank: 0.5},
+				{UniqueKey: []byte("k2"), Rank: 0.25},
+			},
+		}},
+	}
+
+	for _, x := range seq {
+		if err := out.Write(x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `query,took,source.cask,source.version,source.size,hits.key,hits.sort,hits.rank
+a,,example,v1,2,,,
+b,1000000,example,v1,2,azE=,,0.5
+b,1000000,example,v1,2,azI=,,0.25
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected csv\n%s\nexpected\n%s", buf.String(), expect)
+	}
+}
+
+func TestOutputCSVUnknownColumn(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FORMAT_CSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := out.Write(map[string]any{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := out.Write(map[string]any{"a": 1, "b": 2}); err == nil {
+		t.Errorf("column out of header is dropped")
+	}
+}
+
+func TestOutputYAML(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FORMAT_YAML, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seq := []queryResult{
+		{Query: "true", Result: &surface.Result{
+			Source: optimum.Source{Cask: "example", Version: "v1", Size: 2},
+			Hits:   []surface.Hit{{UniqueKey: []byte("k1"), Rank: 0.5}},
+		}},
+		{Query: "b: c", Result: &surface.Result{}},
+	}
+
+	for _, x := range seq {
+		if err := out.Write(x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `- query: "true"
+  source:
+    cask: example
+    version: v1
+    size: 2
+  hits:
+    - key: azE=
+      rank: 0.5
+- query: 'b: c'
+  source:
+    cask: ""
+    version: ""
+    size: 0
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected yaml\n%s\nexpected\n%s", buf.String(), expect)
+	}
+}
+
+func TestOutputYAMLEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FORMAT_YAML, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "[]\n" {
+		t.Errorf("unexpected yaml %q", buf.String())
+	}
+}
+
+func TestOutputCSVColumnsOfFirstRecord(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FORMAT_CSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seq := []map[string]any{
+		{"a": 1, "b": map[string]any{"c": "x"}, "d": []any{map[string]any{"e": true}, map[string]any{"e": false}}},
+		{"a": 2, "d": []any{}},
+	}
+
+	for _, x := range seq {
+		if err := out.Write(x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `a,b.c,d.e
+1,x,true
+1,x,false
+2,,
+`
+	if buf.String() != expect {
+		t.Errorf("unexpected csv\n%s\nexpected\n%s", buf.String(), expect)
+	}
+}
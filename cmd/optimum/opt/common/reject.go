@@ -0,0 +1,143 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+func AboutReject(kind string) string {
+	return fmt.Sprintf(`
+Each line of the dataset is validated before sending: json shall be well-formed
+object without unknown fields, text shall not be empty or exceed 4KB, isPartOf
+shall be absolute URL. The command fails at the first invalid line, reporting
+its number. Use --skip-invalid flag to continue, rejected lines are written to
+the reject file (e.g. data.json.rejected) as is, they can be fixed and uploaded
+again. The summary of accepted and rejected lines is printed at the end.
+
+  optimum %s upload -n example --skip-invalid path/to/data.json
+`, kind)
+}
+
+// Rejects of invalid lines of dataset
+type Rejects struct {
+	File     string
+	Skip     bool
+	Accepted int
+	Rejected int
+
+	fd   *os.File
+	size int64
+
+	// size of reject file after the line at offset, it is not acknowledged yet
+	marks []mark
+	acked int64
+}
+
+type mark struct {
+	offset int64
+	size   int64
+}
+
+// NewRejects creates the reject file for the dataset, the existing file is
+// truncated to the size (e.g. recorded by the checkpoint of resumed upload),
+// so that lines read again are not rejected twice. Invalid lines fail the
+// upload unless skip is defined.
+func NewRejects(source string, file string, skip bool, size int64) (*Rejects, error) {
+	switch {
+	case file == "" && source == STDIN:
+		file = "stdin.rejected"
+	case file == "":
+		file = source + ".rejected"
+	}
+
+	r := &Rejects{File: file, Skip: skip, size: size, acked: size}
+	if !skip {
+		return r, nil
+	}
+
+	if size == 0 {
+		err := os.Remove(file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	fi, err := os.Stat(file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		r.size, r.acked = 0, 0
+		return r, nil
+	case err != nil:
+		return nil, err
+	case fi.Size() < size:
+		r.size, r.acked = fi.Size(), fi.Size()
+		return r, nil
+	}
+
+	if err := os.Truncate(file, size); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Accept the valid line
+func (r *Rejects) Accept() { r.Accepted++ }
+
+// Reject the invalid line, the offset is the position of input after the line.
+// The error is returned if invalid lines are not skipped.
+func (r *Rejects) Reject(line []byte, offset int64, err error) error {
+	r.Rejected++
+	if !r.Skip {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "rejected %s\n", err)
+
+	if r.fd == nil {
+		fd, err := os.OpenFile(r.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		r.fd = fd
+	}
+
+	n, err := r.fd.Write(append(line, '\n'))
+	r.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	r.marks = append(r.marks, mark{offset: offset, size: r.size})
+	return nil
+}
+
+// Size of the reject file that holds lines before the offset
+func (r *Rejects) Size(offset int64) int64 {
+	for len(r.marks) > 0 && r.marks[0].offset <= offset {
+		r.acked = r.marks[0].size
+		r.marks = r.marks[1:]
+	}
+	return r.acked
+}
+
+// Close the reject file and print the summary
+func (r *Rejects) Close() error {
+	if r.fd == nil {
+		fmt.Printf("==> accepted %d, rejected %d\n", r.Accepted, r.Rejected)
+		return nil
+	}
+
+	fmt.Printf("==> accepted %d, rejected %d (see %s)\n", r.Accepted, r.Rejected, r.File)
+	return r.fd.Close()
+}
@@ -0,0 +1,142 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fogfish/curie"
+)
+
+func rejectsOf(t *testing.T, source string, size int64, lines map[int64]string) *Rejects {
+	t.Helper()
+
+	r, err := NewRejects(source, "", true, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, offset := range []int64{10, 20, 30} {
+		if line, has := lines[offset]; has {
+			if err := r.Reject([]byte(line), offset, errors.New("invalid")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return r
+}
+
+func contentOf(t *testing.T, file string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRejectsResume(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "data.json")
+	cask := curie.New("text:example")
+
+	// the upload is interrupted, the line at 20 is not acknowledged
+	ckpt, err := NewCheckpoint(cask, source, 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := rejectsOf(t, source, 0, map[int64]string{10: "a", 30: "c"})
+	if err := ckpt.Save(15, r.Size(15)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ckpt.Save(20, r.Size(20)); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	if s := contentOf(t, r.File); s != "a\nc\n" {
+		t.Errorf("unexpected rejects %q", s)
+	}
+
+	// the upload is resumed from the checkpoint
+	ckpt, err = NewCheckpoint(cask, source, 100, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ckpt.Offset != 20 || ckpt.Rejected != 2 {
+		t.Errorf("unexpected checkpoint at %d with %d bytes of rejects", ckpt.Offset, ckpt.Rejected)
+	}
+
+	r = rejectsOf(t, source, ckpt.Rejected, map[int64]string{30: "c"})
+	r.Close()
+
+	if s := contentOf(t, r.File); s != "a\nc\n" {
+		t.Errorf("rejects are duplicated %q", s)
+	}
+}
+
+func TestRejectsSize(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "data.json")
+
+	r := rejectsOf(t, source, 0, map[int64]string{10: "a", 20: "bb", 30: "ccc"})
+	defer r.Close()
+
+	for _, tt := range []struct {
+		offset int64
+		size   int64
+	}{
+		{5, 0},
+		{10, 2},
+		{25, 5},
+		{20, 5},
+		{30, 9},
+		{100, 9},
+	} {
+		if size := r.Size(tt.offset); size != tt.size {
+			t.Errorf("unexpected size %d at %d, expected %d", size, tt.offset, tt.size)
+		}
+	}
+}
+
+func TestRejectsFresh(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(source+".rejected", []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := rejectsOf(t, source, 0, map[int64]string{20: "b"})
+	r.Close()
+
+	if s := contentOf(t, r.File); s != "b\n" {
+		t.Errorf("unexpected rejects %q", s)
+	}
+}
+
+func TestRejectsNotSkipped(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "data.json")
+
+	r, err := NewRejects(source, "", false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := errors.New("invalid")
+	if err := r.Reject([]byte("a"), 10, invalid); err != invalid {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if _, err := os.Stat(r.File); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("reject file is created")
+	}
+}
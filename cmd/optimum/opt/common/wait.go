@@ -0,0 +1,82 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fogfish/curie"
+	"github.com/fogfish/schemaorg"
+	"github.com/kshard/optimum"
+	"github.com/schollz/progressbar/v3"
+)
+
+// ErrTimeout is returned when the job is not completed within the deadline
+var ErrTimeout = errors.New("timeout")
+
+// Wait defines how command awaits the completion of the job
+type Wait struct {
+	// Block until the job is completed
+	Enabled bool
+
+	// Deadline for the job, zero value means no deadline
+	Timeout time.Duration
+}
+
+// Job is a record about the job
+type Job struct {
+	ID      curie.IRI     `json:"id"`
+	Version string        `json:"version,omitempty"`
+	Job     schemaorg.Url `json:"job"`
+	*optimum.JobStatus
+}
+
+func wait(api *optimum.Client, job Job, about string, w Wait, out *Output) error {
+	if !w.Enabled {
+		if !out.Table() {
+			return out.Write(job)
+		}
+		fmt.Printf("%s (vsn %s) | job %s\n", curie.Reference(job.ID), job.Version, job.Job)
+		return nil
+	}
+
+	bar := progressbar.NewOptions(-1,
+		progressbar.OptionSetWriter(out.Progress()),
+		progressbar.OptionSpinnerType(14),
+		progressbar.OptionSetDescription(about),
+	)
+
+	err := spinner(bar, func() error {
+		status, err := api.WaitJob(context.Background(), job.Job,
+			optimum.WaitOptions{
+				MaxInterval: IDLE_TIME,
+				Timeout:     w.Timeout,
+				Progress: func(status *optimum.JobStatus) {
+					bar.Describe(fmt.Sprintf("%s (vsn %s) | %s ...", curie.Reference(job.ID), job.Version, status.Status))
+				},
+			},
+		)
+		job.JobStatus = status
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: job %s is not completed within %s", ErrTimeout, job.Job, w.Timeout)
+		}
+		return err
+	})
+
+	if !out.Table() && job.JobStatus != nil {
+		if err := out.Write(job); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
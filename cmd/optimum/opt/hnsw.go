@@ -12,11 +12,13 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fogfish/curie"
+	"github.com/fogfish/gurl/v2/http"
 	"github.com/kshard/optimum"
 	"github.com/kshard/optimum/cmd/optimum/encoding"
 	"github.com/kshard/optimum/cmd/optimum/opt/common"
@@ -31,31 +33,103 @@ func init() {
 	rootCmd.AddCommand(hnswCmd)
 
 	hnswCmd.AddCommand(hnswListCmd)
+	withListFlags(hnswListCmd)
+
+	hnswCmd.AddCommand(hnswDescribeCmd)
 
 	hnswCmd.AddCommand(hnswCreateCmd)
 	hnswCreateCmd.Flags().StringVarP(&hnswOpts, "json", "j", "", "json config file")
+	withWaitFlags(hnswCreateCmd)
 
 	hnswCmd.AddCommand(hnswCommitCmd)
+	withWaitFlags(hnswCommitCmd)
 
 	hnswCmd.AddCommand(hnswUploadCmd)
+	withVectorFlags(hnswUploadCmd)
 	hnswUploadCmd.Flags().IntVar(&hnswUploadBuf, "buf", 4, "upload buffer in MB (default 4MB)")
+	hnswUploadCmd.Flags().BoolVar(&hnswUploadResume, "resume", false, "resume interrupted upload from the checkpoint")
+	hnswUploadCmd.Flags().IntVar(&hnswUploadParallel, "parallel", 1, "number of chunks uploaded concurrently")
 
 	hnswCmd.AddCommand(hnswStreamCmd)
-	hnswStreamCmd.Flags().IntVar(&hnswChunkSize, "chunk", 100, "streaming chunk size (default 10)")
+	withVectorFlags(hnswStreamCmd)
+	withChunkFlag(hnswStreamCmd, &hnswStreamChunk, "number of vectors streamed per request")
 
 	hnswCmd.AddCommand(hnswQueryCmd)
+	withVectorFlags(hnswQueryCmd)
 	hnswQueryCmd.Flags().StringVarP(&hnswQueryContent, "text", "t", "", "hash to text associated list, useful for debug purposes")
+	withQueryFlags(hnswQueryCmd)
+	hnswQueryCmd.Flags().StringVar(&hnswQuerySortKey.prefix, "sk-prefix", "", "match sort keys starting with the prefix")
+	hnswQueryCmd.Flags().StringVar(&hnswQuerySortKey.from, "sk-from", "", "match sort keys greater or equal to the bound")
+	hnswQueryCmd.Flags().StringVar(&hnswQuerySortKey.to, "sk-to", "", "match sort keys less or equal to the bound")
+
+	hnswCmd.AddCommand(hnswEvalCmd)
+	withVectorFlags(hnswEvalCmd)
+	hnswEvalCmd.Flags().StringVar(&hnswEval.queries, "queries", "", "file with query vectors")
+	hnswEvalCmd.Flags().StringVar(&hnswEval.truth, "truth", "", "file with indexes of true nearest neighbors per query (e.g. gt.ivecs)")
+	hnswEvalCmd.Flags().StringVar(&hnswEval.base, "base", "", "uploaded dataset, the ground truth is computed by brute force")
+	hnswEvalCmd.Flags().IntVar(&hnswEval.k, "k", 10, "number of nearest neighbors to evaluate")
+	hnswEvalCmd.Flags().IntVar(&hnswEval.limit, "limit", 0, "number of queries to evaluate (all by default)")
+	hnswEvalCmd.Flags().IntVar(&queryTune.EfSearch, "ef-search", 0, "number of candidates evaluated during the search (server default if not defined)")
+	hnswEvalCmd.Flags().Float32Var(&queryTune.Distance, "max-distance", 0, "maximum distance to nearest neighbors (server default if not defined)")
+	hnswEvalCmd.MarkFlagRequired("queries")
+
+	hnswCmd.AddCommand(hnswGetCmd)
+	hnswGetCmd.Flags().StringVarP(&hnswGetFile, "file", "f", "", "file with unique keys, one per line")
+	withChunkFlag(hnswGetCmd, &hnswGetChunk, "number of keys fetched per request")
+
+	hnswCmd.AddCommand(hnswDeleteCmd)
+	withChunkFlag(hnswDeleteCmd, &hnswDeleteChunk, "number of keys deleted per request")
 
 	hnswCmd.AddCommand(hnswRemoveCmd)
 }
 
 var (
-	hnswOpts         string
-	hnswUploadBuf    int
-	hnswChunkSize    int
-	hnswQueryContent string
+	hnswOpts           string
+	hnswUploadBuf      int
+	hnswUploadResume   bool
+	hnswUploadParallel int
+	hnswStreamChunk    int
+	hnswGetChunk       int
+	hnswDeleteChunk    int
+	hnswQueryContent   string
+	hnswQuerySortKey   struct{ prefix, from, to string }
+	hnswGetFile        string
+	hnswEval           struct {
+		queries, truth, base string
+		k, limit             int
+	}
 )
 
+// flags of commands that read vectors from the file
+func withVectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&hnswInput.Format, "format", "", "format of the file: text, fvecs, bvecs, ivecs, npy, csv, tsv or jsonl (derived from extension by default)")
+	cmd.Flags().StringVar(&hnswInput.KeyColumn, "key-column", "", "column of csv/tsv file with unique key, either index or name (default 0)")
+	cmd.Flags().BoolVar(&hnswInput.Header, "header", false, "csv/tsv file starts with header")
+}
+
+var hnswInput encoding.Options
+
+// about formats of vector files
+const hnswAboutFormats = `
+Besides textual format, vectors are read from files in other formats. The format
+is derived from the file extension or defined by --format flag. Otherwise, it is
+sniffed from the content (npy, jsonl or text), e.g. for standard input:
+
+  text  (.txt)    unique key followed by vector, space separated;
+  fvecs (.fvecs)  ANN-benchmark vectors of float32;
+  bvecs (.bvecs)  ANN-benchmark vectors of uint8;
+  ivecs (.ivecs)  ANN-benchmark vectors of int32;
+  npy   (.npy)    NumPy two dimensional array of float32 or float16;
+  csv   (.csv)    comma separated unique key and vector components, the key
+                  column is defined by --key-column, use --header if the file
+                  has one;
+  tsv   (.tsv)    tab separated values, similar to csv;
+  jsonl (.jsonl)  JSON object per line {"id": "key", "v": [0.1, ...]}.
+
+The index of vector within the file is used as unique key for formats that do
+not carry keys (fvecs, bvecs, ivecs and npy).
+`
+
 var hnswCmd = &cobra.Command{
 	Use:   "hnsw",
 	Short: "Operates `hnsw` data structures.",
@@ -121,7 +195,42 @@ func hnswList(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	return common.List(optimum.New(cli, host), TYPE_HNSW)
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return common.List(optimum.New(cli, host), TYPE_HNSW, listFilter, func() common.Config { return &surface.Config{} }, out)
+}
+
+//------------------------------------------------------------------------------
+
+var hnswDescribeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Describe instance of `hnsw` data structure.",
+	Long:  common.AboutDescribe(TYPE_HNSW, ""),
+	Example: `
+optimum hnsw describe -u $HOST -n example
+optimum hnsw describe -u $HOST -r $ROLE -n example
+`,
+	SilenceUsage: true,
+	RunE:         hnswDescribe,
+}
+
+func hnswDescribe(cmd *cobra.Command, args []string) (err error) {
+	cli, err := stack()
+	if err != nil {
+		return err
+	}
+
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return common.Describe(optimum.New(cli, host), curie.New("%s:%s", TYPE_HNSW, name), &surface.Config{}, out)
 }
 
 //------------------------------------------------------------------------------
@@ -145,12 +254,17 @@ Config algorithm through primary parameters:
 
   - "surface" is vector distance function.
 
+  - "dimension" of vectors (optional), input of upload, stream and query is
+    validated against it before sending. The check is skipped with warning
+    if the instance is not accessible (e.g. role of data plane only).
+
 Example configuration and default values:	
   {
     "m":  8,                // number in range of [4, 1024]
     "m0": 64,               // number in range of [4, 1024]
     "efConstruction": 200,  // number in range of [200, 1000]
-    "surface": "cosine"     // enum {"cosine", "euclidean"}
+    "surface": "cosine",    // enum {"cosine", "euclidean"}
+    "dimension": 1024       // number in range of [1, 65536], optional
   }
 
 `),
@@ -168,7 +282,13 @@ func hnswCreate(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	return common.Create(optimum.New(cli, host), curie.New("%s:%s", TYPE_HNSW, name), hnswOpts)
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return common.Create(optimum.New(cli, host), curie.New("%s:%s", TYPE_HNSW, name), hnswOpts, &surface.Config{}, waitFor(), out)
 }
 
 //------------------------------------------------------------------------------
@@ -180,6 +300,8 @@ var hnswCommitCmd = &cobra.Command{
 	Example: `
 optimum hnsw commit -u $HOST -n example
 optimum hnsw commit -u $HOST -r $ROLE -n example
+optimum hnsw commit -u $HOST -n example --timeout 1h
+optimum hnsw commit -u $HOST -n example --no-wait
 `,
 	SilenceUsage: true,
 	RunE:         hnswCommit,
@@ -191,7 +313,13 @@ func hnswCommit(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	return common.Commit(optimum.New(cli, host), curie.New("%s:%s", TYPE_HNSW, name))
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return common.Commit(optimum.New(cli, host), curie.New("%s:%s", TYPE_HNSW, name), waitFor(), out)
 }
 
 //------------------------------------------------------------------------------
@@ -200,9 +328,11 @@ var hnswUploadCmd = &cobra.Command{
 	Use:   "upload",
 	Short: "Upload `hnsw` datasets.",
 	Long: `
-Upload "hnsw" dataset to server. It accepts only textual format to represent
-embedding vectors. Each line of the file should start with unique key, followed
-by the corresponding vector. The unique key length should not exceeding 32 bytes:
+Upload "hnsw" dataset to server. Embedding vectors are read from the file in
+textual format, unless other format is used (see below). Each line of the
+textual file should start with unique key, followed by the corresponding vector. The unique key length should not exceeding 32 bytes.
+Key and components are separated by any whitespace, blank lines and comments
+(lines starting with #) are skipped:
 
   example_key_a 0.24116 ... -0.26098 -0.0079604
 	example_key_b 0.34601 ... -0.66865 -0.0486001
@@ -212,11 +342,16 @@ The format allows hexadecimal encoding for keys, if it starts with "0x" prefix.
 
   0xd857f9dc157c28e8e07c569c5992dee4f3486b4c -0.097231 ... -0.001681 0.154977
   0xaeb3e05ab60520cd947455f2130d6cf1f6103243 -0.008007 ... -0.098503 0.057056
-
-`,
+` + hnswAboutFormats + common.AboutInput() + common.AboutCheckpoint(TYPE_HNSW),
 	Example: `
 optimum hnsw upload -u $HOST -n example path/to/data.txt
 optimum hnsw upload -u $HOST -r $ROLE -n example path/to/data.txt
+optimum hnsw upload -u $HOST -n example --resume path/to/data.txt
+optimum hnsw upload -u $HOST -n example --parallel 8 path/to/data.txt
+optimum hnsw upload -u $HOST -n example path/to/data.fvecs
+optimum hnsw upload -u $HOST -n example --key-column id --format csv path/to/data.export
+optimum hnsw upload -u $HOST -n example path/to/data.fvecs.gz
+cat path/to/data.txt | optimum hnsw upload -u $HOST -n example -
 `,
 	SilenceUsage: true,
 	Args:         cobra.ExactArgs(1),
@@ -224,53 +359,70 @@ optimum hnsw upload -u $HOST -r $ROLE -n example path/to/data.txt
 }
 
 func hnswUpload(cmd *cobra.Command, args []string) (err error) {
-	fd, err := os.Open(args[0])
+	fd, err := common.OpenInput(args[0], "==> uploading")
 	if err != nil {
 		return err
 	}
 	defer fd.Close()
 
-	fi, err := fd.Stat()
+	cli, err := stack()
 	if err != nil {
 		return err
 	}
 
-	cli, err := stack()
+	cask := curie.New("%s:%s", TYPE_HNSW, name)
+	ckpt, err := common.NewCheckpoint(cask, args[0], fd.Size, hnswUploadResume)
 	if err != nil {
 		return err
 	}
 
-	stream := surface.NewWriter(cli, host, curie.New("%s:%s", TYPE_HNSW, name), hnswUploadBuf*1024*1024)
+	dim := hnswDimension(cli, cask)
 
-	r := io.TeeReader(fd,
-		progressbar.DefaultBytes(
-			fi.Size(),
-			"==> uploading",
-		),
+	stream := surface.NewWriter(cli, host, cask, hnswUploadBuf*1024*1024,
+		surface.WithParallel(hnswUploadParallel),
+		surface.WithDimension(dim),
 	)
 
-	scanner := encoding.New(r)
+	scanner, err := encoding.NewReader(fd, fd.File, hnswInput)
+	if err != nil {
+		return err
+	}
+
+	// the dataset is read from the checkpoint
+	if err := scanner.Skip(ckpt.Offset); err != nil {
+		return err
+	}
+
 	for scanner.Scan() {
-		err := stream.Write(context.Background(),
+		if err := hnswCheckDimension(dim, scanner); err != nil {
+			return err
+		}
+
+		err := stream.WriteAt(context.Background(),
 			surface.Vector{
 				UniqueKey: scanner.UniqueKey(),
 				Vector:    scanner.Vector(),
 			},
+			scanner.Offset(),
 		)
 		if err != nil {
 			return err
 		}
+
+		if err := ckpt.Save(stream.Offset(), 0); err != nil {
+			return err
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		return err
 	}
 
-	if err := stream.Sync(context.Background()); err != nil {
+	if err := stream.Close(context.Background()); err != nil {
 		return err
 	}
 
-	return nil
+	return ckpt.Remove()
 }
 
 //------------------------------------------------------------------------------
@@ -279,9 +431,11 @@ var hnswStreamCmd = &cobra.Command{
 	Use:   "stream",
 	Short: "Stream `hnsw` datasets.",
 	Long: `
-Stream "hnsw" dataset to server. It accepts only textual format to represent
-embedding vectors. Each line of the file should start with unique key, followed
-by the corresponding vector. The unique key length should not exceeding 32 bytes:
+Stream "hnsw" dataset to server. Embedding vectors are read from the file in
+textual format, unless other format is used (see below). Each line of the
+textual file should start with unique key, followed by the corresponding vector. The unique key length should not exceeding 32 bytes.
+Key and components are separated by any whitespace, blank lines and comments
+(lines starting with #) are skipped:
 
   example_key_a 0.24116 ... -0.26098 -0.0079604
   example_key_b 0.34601 ... -0.66865 -0.0486001
@@ -291,11 +445,11 @@ The format allows hexadecimal encoding for keys, if it starts with "0x" prefix.
 
   0xd857f9dc157c28e8e07c569c5992dee4f3486b4c -0.097231 ... -0.001681 0.154977
   0xaeb3e05ab60520cd947455f2130d6cf1f6103243 -0.008007 ... -0.098503 0.057056
-
-`,
+` + hnswAboutFormats + common.AboutInput(),
 	Example: `
 optimum hnsw stream -u $HOST -n example path/to/data.txt
 optimum hnsw stream -u $HOST -r $ROLE -n example path/to/data.txt
+optimum hnsw stream -u $HOST -n example --format fvecs - < path/to/data.fvecs
 `,
 	SilenceUsage: true,
 	Args:         cobra.ExactArgs(1),
@@ -303,35 +457,34 @@ optimum hnsw stream -u $HOST -r $ROLE -n example path/to/data.txt
 }
 
 func hnswStream(cmd *cobra.Command, args []string) (err error) {
-	fd, err := os.Open(args[0])
+	fd, err := common.OpenInput(args[0], "==> uploading")
 	if err != nil {
 		return err
 	}
 	defer fd.Close()
 
-	fi, err := fd.Stat()
+	cli, err := stack()
 	if err != nil {
 		return err
 	}
 
-	cli, err := stack()
+	cask := curie.New("%s:%s", TYPE_HNSW, name)
+	dim := hnswDimension(cli, cask)
+
+	api := surface.New(cli, host, surface.WithDimension(dim))
+
+	scanner, err := encoding.NewReader(fd, fd.File, hnswInput)
 	if err != nil {
 		return err
 	}
 
-	api := surface.New(cli, host)
-
-	r := io.TeeReader(fd,
-		progressbar.DefaultBytes(
-			fi.Size(),
-			"==> uploading",
-		),
-	)
-
-	scanner := encoding.New(r)
 	for scanner.Scan() {
 		bag := make([]surface.Vector, 0)
-		for i, has := 0, true; i < hnswChunkSize && has; i, has = i+1, scanner.Scan() {
+		for i, has := 0, true; i < hnswStreamChunk && has; i, has = i+1, scanner.Scan() {
+			if err := hnswCheckDimension(dim, scanner); err != nil {
+				return err
+			}
+
 			bag = append(bag, surface.Vector{
 				UniqueKey: scanner.UniqueKey(),
 				Vector:    scanner.Vector(),
@@ -339,7 +492,7 @@ func hnswStream(cmd *cobra.Command, args []string) (err error) {
 		}
 
 		if len(bag) > 0 {
-			err := api.Write(context.Background(), curie.New("%s:%s", TYPE_HNSW, name), bag)
+			err := api.Write(context.Background(), cask, bag)
 			if err != nil {
 				return err
 			}
@@ -366,12 +519,32 @@ identity of query, followed by the corresponding vector:
   example_query_a 0.24116 ... -0.26098 -0.0079604
 	example_query_b 0.34601 ... -0.66865 -0.0486001
 
-The file format is identical to the upload and can be re-used as is.
-`,
+The file format is identical to the upload and can be re-used as is. Queries
+are sent in batches (--batch), the client falls back to concurrent queries
+(--concurrency) if server does not support batches.
+
+Use --k, --ef-search and --max-distance flags to tune the query. The jsonl
+format overrides them per query, the tuning attributes are optional:
+
+  {"id": "example_query_a", "v": [0.24116, ...], "k": 10, "efSearch": 200}
+  {"id": "example_query_b", "v": [0.34601, ...], "distance": 0.35}
+
+Use --sk-prefix, --sk-from and --sk-to flags to restrict the query to vectors
+with matching sort key (e.g. partition of tenant or time). The range is
+inclusive, the prefix and the range are combined if both are defined. Sort
+keys allow hexadecimal encoding, if they start with "0x" prefix.
+` + hnswAboutFormats + common.AboutInput(),
 	Example: `
 optimum hnsw query -u $HOST -n example path/to/query.txt
 optimum hnsw query -u $HOST -r $ROLE -n example path/to/query.txt
 optimum hnsw query -u $HOST -r $ROLE -n example -t path/to/text-map.txt path/to/query.txt
+optimum hnsw query -u $HOST -n example path/to/query.npy
+optimum hnsw query -u $HOST -n example --concurrency 16 path/to/query.fvecs
+optimum hnsw query -u $HOST -n example --k 10 --ef-search 200 path/to/query.fvecs
+optimum hnsw query -u $HOST -n example path/to/query.jsonl
+optimum hnsw query -u $HOST -n example --sk-prefix tenant-a/ path/to/query.txt
+optimum hnsw query -u $HOST -n example --sk-from 2024-01 --sk-to 2024-06 path/to/query.txt
+optimum hnsw query -u $HOST -n example path/to/query.txt.zst
 `,
 	SilenceUsage: true,
 	Args:         cobra.ExactArgs(1),
@@ -381,7 +554,7 @@ optimum hnsw query -u $HOST -r $ROLE -n example -t path/to/text-map.txt path/to/
 func hnswQuery(cmd *cobra.Command, args []string) (err error) {
 	hashmap := hnswTextHashMap()
 
-	fd, err := os.Open(args[0])
+	fd, err := common.OpenInput(args[0], "")
 	if err != nil {
 		return err
 	}
@@ -392,29 +565,73 @@ func hnswQuery(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	api := surface.New(cli, host)
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
 
-	scanner := encoding.New(fd)
-	for scanner.Scan() {
-		query := surface.Query{Query: scanner.Vector()}
-		rs, err := api.Query(context.Background(), curie.New("%s:%s", TYPE_HNSW, name), query)
+	sk, err := hnswSortKey()
+	if err != nil {
+		return err
+	}
+
+	cask := curie.New("%s:%s", TYPE_HNSW, name)
+	dim := hnswDimension(cli, cask)
+
+	api := surface.New(cli, host,
+		surface.WithDimension(dim),
+		surface.WithConcurrency(queryConcurrency),
+	)
+
+	scanner, err := encoding.NewReader(fd, fd.File, hnswInput)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, queryBatch)
+	seq := make([]surface.Query, 0, queryBatch)
+	flush := func() error {
+		rs, err := api.QueryBatch(context.Background(), cask, seq)
 		if err != nil {
 			return err
 		}
 
-		id := fmt.Sprintf("0x%x", scanner.UniqueKey())
-		fmt.Printf("Query %s (took %s) | %s (vsn %s, size %d)\n", id, rs.Took, rs.Source.Cask, rs.Source.Version, rs.Source.Size)
-		for _, hit := range rs.Hits {
-			hid := fmt.Sprintf("0x%x", hit.UniqueKey)
-			fmt.Printf("  %f : %32s \n", hit.Rank, hid)
+		for i := range rs {
+			if err := hnswQueryWrite(out, hashmap, ids[i], &rs[i]); err != nil {
+				return err
+			}
 		}
 
-		if hashmap != nil {
-			fmt.Printf("\n\nQuery (took %s) > %s\n", rs.Took, hnswTextValue(hashmap, id))
-			for _, hit := range rs.Hits {
-				hid := fmt.Sprintf("0x%x", hit.UniqueKey)
-				fmt.Printf("  %f : %s\n", hit.Rank, hnswTextValue(hashmap, hid))
-			}
+		ids, seq = ids[:0], seq[:0]
+		return nil
+	}
+
+	for scanner.Scan() {
+		if err := hnswCheckDimension(dim, scanner); err != nil {
+			return err
+		}
+
+		var tuning encoding.Tuning
+		if tuner, ok := scanner.(encoding.Tuner); ok {
+			tuning = tuner.Tuning()
+		}
+		tuning = queryTuning(tuning)
+
+		ids = append(ids, fmt.Sprintf("0x%x", scanner.UniqueKey()))
+		seq = append(seq, surface.Query{
+			K:        tuning.K,
+			EfSearch: tuning.EfSearch,
+			Distance: tuning.Distance,
+			Query:    scanner.Vector(),
+			SortKey:  sk,
+		})
+		if len(seq) < queryBatch {
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return err
 		}
 	}
 
@@ -422,9 +639,100 @@ func hnswQuery(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
+	return flush()
+}
+
+func hnswQueryWrite(out *common.Output, hashmap map[string]string, id string, rs *surface.Result) error {
+	if !out.Table() {
+		return out.Write(hnswQueryResult{Query: id, Result: rs})
+	}
+
+	fmt.Printf("Query %s (took %s) | %s (vsn %s, size %d)\n", id, rs.Took, rs.Source.Cask, rs.Source.Version, rs.Source.Size)
+	for _, hit := range rs.Hits {
+		hid := fmt.Sprintf("0x%x", hit.UniqueKey)
+		fmt.Printf("  %f : %32s \n", hit.Rank, hid)
+	}
+
+	if hashmap != nil {
+		fmt.Printf("\n\nQuery (took %s) > %s\n", rs.Took, hnswTextValue(hashmap, id))
+		for _, hit := range rs.Hits {
+			hid := fmt.Sprintf("0x%x", hit.UniqueKey)
+			fmt.Printf("  %f : %s\n", hit.Rank, hnswTextValue(hashmap, hid))
+		}
+	}
+
+	return nil
+}
+
+// dimension of vectors in the instance, zero if it is unknown. The check is
+// a safeguard, the failed lookup (e.g. the role has no access to the control
+// plane) is reported as warning and the dimension is locked by the first vector.
+func hnswDimension(cli http.Stack, cask curie.IRI) int {
+	inst, err := optimum.New(cli, host).Cask(context.Background(), cask)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "==> dimension of %s is not checked: %s\n", curie.Reference(cask), err)
+		return 0
+	}
+
+	dim, err := surface.DimensionOf(*inst)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "==> dimension of %s is not checked: %s\n", curie.Reference(cask), err)
+		return 0
+	}
+
+	return dim
+}
+
+// input is validated before sending, the error refers the vector
+func hnswCheckDimension(dim int, scanner encoding.Reader) error {
+	if dim != 0 && len(scanner.Vector()) != dim {
+		return fmt.Errorf("invalid input (offset %d): %w", scanner.Offset(),
+			&surface.DimensionError{
+				UniqueKey: scanner.UniqueKey(),
+				Expected:  dim,
+				Actual:    len(scanner.Vector()),
+			},
+		)
+	}
+
 	return nil
 }
 
+// sort key predicate of query, it is defined by --sk-* flags
+func hnswSortKey() (*surface.SortKey, error) {
+	if hnswQuerySortKey.prefix == "" && hnswQuerySortKey.from == "" && hnswQuerySortKey.to == "" {
+		return nil, nil
+	}
+
+	var err error
+	sk := &surface.SortKey{}
+	for _, x := range []struct {
+		flag  string
+		value string
+		key   *[]uint8
+	}{
+		{"sk-prefix", hnswQuerySortKey.prefix, &sk.Prefix},
+		{"sk-from", hnswQuerySortKey.from, &sk.From},
+		{"sk-to", hnswQuerySortKey.to, &sk.To},
+	} {
+		if x.value == "" {
+			continue
+		}
+
+		if *x.key, err = encoding.DecodeKey(x.value); err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", x.flag, err)
+		}
+	}
+
+	return sk, sk.Validate()
+}
+
+// result of the query, it is associated with query identity
+type hnswQueryResult struct {
+	Query string `json:"query"`
+	*surface.Result
+}
+
 func hnswTextHashMap() map[string]string {
 	if hnswQueryContent == "" {
 		return nil
@@ -456,6 +764,460 @@ func hnswTextValue(hashmap map[string]string, key string) string {
 
 //------------------------------------------------------------------------------
 
+var hnswGetCmd = &cobra.Command{
+	Use:   "get [key ...]",
+	Short: "Get vectors stored in `hnsw` instance by unique key.",
+	Long: `
+Get vectors stored in "hnsw" data structure instance, it is useful to inspect
+the data (e.g. debug ranking). Keys are either given as arguments or read from
+the file, where each line is unique key. The format allows hexadecimal encoding
+for keys, if it starts with "0x" prefix.
+
+Vectors are fetched in batches, keys that are not found are reported at the end.
+` + common.AboutInput(),
+	Example: `
+optimum hnsw get -u $HOST -n example example_key_a
+optimum hnsw get -u $HOST -n example 0xd857f9dc157c28e8e07c569c5992dee4f3486b4c
+optimum hnsw get -u $HOST -n example -f path/to/keys.txt -o jsonl
+`,
+	SilenceUsage: true,
+	RunE:         hnswGet,
+}
+
+func hnswGet(cmd *cobra.Command, args []string) (err error) {
+	keys, err := hnswGetKeys(args)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("keys are not defined")
+	}
+
+	cli, err := stack()
+	if err != nil {
+		return err
+	}
+
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	api := surface.New(cli, host)
+	cask := curie.New("%s:%s", TYPE_HNSW, name)
+
+	if len(keys) == 1 {
+		v, err := api.Get(context.Background(), cask, keys[0])
+		if err != nil {
+			return err
+		}
+
+		return hnswGetWrite(out, *v)
+	}
+
+	n := 0
+	for at := 0; at < len(keys); at += hnswGetChunk {
+		bag, err := api.MultiGet(context.Background(), cask, keys[at:min(at+hnswGetChunk, len(keys))])
+		if err != nil {
+			return err
+		}
+
+		for _, v := range bag {
+			if err := hnswGetWrite(out, v); err != nil {
+				return err
+			}
+		}
+		n += len(bag)
+	}
+
+	if n < len(keys) {
+		fmt.Fprintf(os.Stderr, "==> %d of %d keys are not found\n", len(keys)-n, len(keys))
+	}
+
+	return nil
+}
+
+// keys are read from arguments and the file
+func hnswGetKeys(args []string) ([][]uint8, error) {
+	keys := make([][]uint8, 0, len(args))
+	for _, arg := range args {
+		key, err := encoding.DecodeKey(arg)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if hnswGetFile == "" {
+		return keys, nil
+	}
+
+	fd, err := common.OpenInput(hnswGetFile, "")
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	scanner := encoding.NewKeys(fd)
+	for scanner.Scan() {
+		key, err := scanner.UniqueKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, scanner.Err()
+}
+
+// vector stored in the instance
+type hnswGetResult struct {
+	Key     string    `json:"key"`
+	SortKey string    `json:"sk,omitempty"`
+	Vector  []float32 `json:"v"`
+}
+
+func hnswGetWrite(out *common.Output, v surface.Vector) error {
+	result := hnswGetResult{Key: fmt.Sprintf("0x%x", v.UniqueKey), Vector: v.Vector}
+	if len(v.SortKey) != 0 {
+		result.SortKey = fmt.Sprintf("0x%x", v.SortKey)
+	}
+
+	if !out.Table() {
+		return out.Write(result)
+	}
+
+	fmt.Printf("%s | sk %s | dim %d\n", result.Key, result.SortKey, len(result.Vector))
+	fmt.Printf("  %v\n", result.Vector)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var hnswEvalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Evaluate quality of `hnsw` instance against ground truth.",
+	Long: `
+Evaluate quality of "hnsw" data structure instance, it is useful for tuning
+parameters of the graph (m, m0, efConstruction) and the search (efSearch).
+Query vectors are sent one by one, retrieved neighbors are compared against
+true nearest neighbors:
+
+  recall@k  fraction of true k nearest neighbors that are retrieved;
+  MRR       mean reciprocal rank of the true nearest neighbor;
+  nDCG@k    normalized discounted cumulative gain of retrieved neighbors.
+
+The latency of queries is reported as mean, percentiles (p50, p90, p99) and
+maximum. It is measured by the client, including the network.
+
+The ground truth is either the file (--truth), where each vector is indexes of
+true nearest neighbors of the query ordered by distance (e.g. gt.ivecs of
+ANN-benchmarks), or computed locally by brute force from the uploaded dataset
+(--base) using the distance function of the instance. The indexes refer to
+vectors of the dataset file, they match unique keys of vectors uploaded from
+formats that do not carry keys (fvecs, bvecs, ivecs and npy). The brute force
+is quadratic, use --limit to evaluate the subset of queries.
+
+The format flags apply to query vectors and the dataset.
+` + hnswAboutFormats,
+	Example: `
+optimum hnsw eval -u $HOST -n example --queries path/to/query.fvecs --truth path/to/gt.ivecs
+optimum hnsw eval -u $HOST -n example --queries path/to/query.fvecs --truth path/to/gt.ivecs --k 10 --ef-search 200
+optimum hnsw eval -u $HOST -n example --queries path/to/query.fvecs --base path/to/base.fvecs --limit 100
+optimum hnsw eval -u $HOST -n example --queries path/to/query.fvecs --truth path/to/gt.ivecs -o json
+`,
+	SilenceUsage: true,
+	RunE:         hnswEvaluate,
+}
+
+func hnswEvaluate(cmd *cobra.Command, args []string) (err error) {
+	if (hnswEval.truth == "") == (hnswEval.base == "") {
+		return fmt.Errorf("either --truth or --base is required")
+	}
+
+	if hnswEval.k <= 0 {
+		return fmt.Errorf("invalid k = %d, it shall be positive", hnswEval.k)
+	}
+
+	cli, err := stack()
+	if err != nil {
+		return err
+	}
+
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	cask := curie.New("%s:%s", TYPE_HNSW, name)
+	dim := hnswDimension(cli, cask)
+
+	queries, err := hnswEvalQueries(dim)
+	if err != nil {
+		return err
+	}
+
+	var truth func(int) []string
+	if hnswEval.truth != "" {
+		truth, err = hnswEvalTruth(len(queries))
+	} else {
+		truth, err = hnswEvalBruteForce(cli, cask, queries)
+	}
+	if err != nil {
+		return err
+	}
+
+	api := surface.New(cli, host, surface.WithDimension(dim))
+	eval := common.NewEval(hnswEval.k)
+	tuning := queryTuning(encoding.Tuning{K: hnswEval.k})
+
+	bar := progressbar.NewOptions(len(queries),
+		progressbar.OptionSetWriter(out.Progress()),
+		progressbar.OptionSetDescription("==> evaluating"),
+		progressbar.OptionShowCount(),
+	)
+
+	for i, vector := range queries {
+		query := surface.Query{
+			K:        tuning.K,
+			EfSearch: tuning.EfSearch,
+			Distance: tuning.Distance,
+			Query:    vector,
+		}
+
+		t := time.Now()
+		rs, err := api.Query(context.Background(), cask, query)
+		if err != nil {
+			return err
+		}
+		latency := time.Since(t)
+
+		hits := make([]string, len(rs.Hits))
+		for j, hit := range rs.Hits {
+			hits[j] = string(hit.UniqueKey)
+		}
+
+		eval.Add(hits, truth(i), latency)
+		bar.Add(1)
+	}
+	bar.Finish()
+
+	report := eval.Report()
+	if !out.Table() {
+		return out.Write(report)
+	}
+
+	fmt.Printf("\n%s | queries %d\n", name, report.Queries)
+	fmt.Printf("  recall@%d : %.4f\n", report.K, report.Recall)
+	fmt.Printf("  MRR      : %.4f\n", report.MRR)
+	fmt.Printf("  nDCG@%d   : %.4f\n", report.K, report.NDCG)
+	fmt.Printf("  latency  : mean %s, p50 %s, p90 %s, p99 %s, max %s\n",
+		report.Latency.Mean, report.Latency.P50, report.Latency.P90, report.Latency.P99, report.Latency.Max)
+
+	return nil
+}
+
+// query vectors of evaluation, limited by --limit flag
+func hnswEvalQueries(dim int) ([][]float32, error) {
+	fd, err := common.OpenInput(hnswEval.queries, "")
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	scanner, err := encoding.NewReader(fd, fd.File, hnswInput)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := make([][]float32, 0)
+	for (hnswEval.limit == 0 || len(queries) < hnswEval.limit) && scanner.Scan() {
+		if err := hnswCheckDimension(dim, scanner); err != nil {
+			return nil, err
+		}
+
+		if dim == 0 && len(queries) > 0 && len(scanner.Vector()) != len(queries[0]) {
+			return nil, fmt.Errorf("invalid input (offset %d): %w", scanner.Offset(),
+				&surface.DimensionError{Expected: len(queries[0]), Actual: len(scanner.Vector())})
+		}
+
+		queries = append(queries, scanner.Vector())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("queries are not defined")
+	}
+
+	return queries, nil
+}
+
+// ground truth from the file, indexes of neighbors are used as unique keys
+func hnswEvalTruth(n int) (func(int) []string, error) {
+	fd, err := common.OpenInput(hnswEval.truth, "")
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	scanner, err := encoding.NewReader(fd, fd.File, encoding.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	truth := make([][]string, 0, n)
+	for len(truth) < n && scanner.Scan() {
+		seq := scanner.Vector()
+		keys := make([]string, len(seq))
+		for i, x := range seq {
+			// indexes are exact within the precision of float32
+			if x < 0 || x >= 1<<24 || x != float32(int(x)) {
+				return nil, fmt.Errorf("invalid ground truth (offset %d): index %v is not supported", scanner.Offset(), x)
+			}
+			keys[i] = strconv.Itoa(int(x))
+		}
+		truth = append(truth, keys)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(truth) < n {
+		return nil, fmt.Errorf("ground truth has %d vectors, it is less than %d queries", len(truth), n)
+	}
+
+	return func(i int) []string { return truth[i] }, nil
+}
+
+// ground truth computed by brute force from the dataset, it requires the
+// distance function of the instance
+func hnswEvalBruteForce(cli http.Stack, cask curie.IRI, queries [][]float32) (func(int) []string, error) {
+	inst, err := optimum.New(cli, host).Cask(context.Background(), cask)
+	if err != nil {
+		return nil, fmt.Errorf("distance function of %s is unknown: %w", curie.Reference(cask), err)
+	}
+
+	conf, err := surface.ConfigOf(*inst)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := conf.Surface
+	if kind == "" {
+		kind = surface.DefaultConfig().Surface
+	}
+
+	fd, err := common.OpenInput(hnswEval.base, "==> ground truth")
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	scanner, err := encoding.NewReader(fd, fd.File, hnswInput)
+	if err != nil {
+		return nil, err
+	}
+
+	bf := common.NewBruteForce(kind, hnswEval.k, queries)
+	for scanner.Scan() {
+		if err := hnswCheckDimension(len(queries[0]), scanner); err != nil {
+			return nil, err
+		}
+
+		bf.Add(string(scanner.UniqueKey()), scanner.Vector())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return bf.Truth, nil
+}
+
+//------------------------------------------------------------------------------
+
+var hnswDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete vectors from `hnsw` instance by unique key.",
+	Long: `
+Delete vectors from "hnsw" data structure instance. It accepts textual file,
+where each line is unique key of the vector. The format allows hexadecimal
+encoding for keys, if it starts with "0x" prefix. Blank lines and comments
+(lines starting with #) are skipped:
+
+  example_key_a
+  0xd857f9dc157c28e8e07c569c5992dee4f3486b4c
+
+Vectors are deleted immediately, without commit.
+` + common.AboutInput(),
+	Example: `
+optimum hnsw delete -u $HOST -n example path/to/keys.txt
+optimum hnsw delete -u $HOST -r $ROLE -n example path/to/keys.txt
+echo "example_key_a" | optimum hnsw delete -u $HOST -n example -
+`,
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(1),
+	RunE:         hnswDelete,
+}
+
+func hnswDelete(cmd *cobra.Command, args []string) (err error) {
+	fd, err := common.OpenInput(args[0], "==> deleting")
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	cli, err := stack()
+	if err != nil {
+		return err
+	}
+
+	api := surface.New(cli, host)
+	cask := curie.New("%s:%s", TYPE_HNSW, name)
+
+	n := 0
+	bag := make([][]uint8, 0, hnswDeleteChunk)
+	scanner := encoding.NewKeys(fd)
+	for scanner.Scan() {
+		key, err := scanner.UniqueKey()
+		if err != nil {
+			return err
+		}
+
+		bag = append(bag, key)
+		if len(bag) < hnswDeleteChunk {
+			continue
+		}
+
+		if err := api.Delete(context.Background(), cask, bag); err != nil {
+			return err
+		}
+		n += len(bag)
+		bag = bag[:0]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := api.Delete(context.Background(), cask, bag); err != nil {
+		return err
+	}
+	n += len(bag)
+
+	fmt.Printf("==> deleted %d vectors\n", n)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
 var hnswRemoveCmd = &cobra.Command{
 	Use:   "remove",
 	Short: "Remove instance of `hnsw` data structure.",
@@ -10,11 +10,13 @@ package opt
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -23,15 +25,38 @@ import (
 	"github.com/fogfish/gurl/v2/http"
 	"github.com/fogfish/gurl/x/awsapi"
 	"github.com/jdxcode/netrc"
+	"github.com/kshard/optimum"
+	"github.com/kshard/optimum/cmd/optimum/encoding"
+	"github.com/kshard/optimum/cmd/optimum/opt/common"
 	"github.com/spf13/cobra"
 )
 
+// Exit codes of the command line utility
+const (
+	EXIT_FAILURE    = 1 // transport or any other error
+	EXIT_JOB_FAILED = 2 // the job is completed with failure
+	EXIT_TIMEOUT    = 3 // the job is not completed within the deadline
+)
+
 // Execute is entry point for cobra cli application
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		e := err.Error()
 		fmt.Println(strings.ToUpper(e[:1]) + e[1:])
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+func exitCode(err error) int {
+	var failed *optimum.JobFailed
+
+	switch {
+	case errors.As(err, &failed):
+		return EXIT_JOB_FAILED
+	case errors.Is(err, common.ErrTimeout):
+		return EXIT_TIMEOUT
+	default:
+		return EXIT_FAILURE
 	}
 }
 
@@ -42,6 +67,9 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&exid, "external-id", "e", "", "ExternalID associated with the role")
 	rootCmd.PersistentFlags().StringVarP(&profile, "profile", "p", "", "the access profile at ~/.aws/config")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug output")
+	rootCmd.PersistentFlags().StringVarP(&format, "output", "o", common.FORMAT_TABLE, "output format: table, json, jsonl, yaml or csv")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 3, "number of retries of failed requests (e.g. throttled or unavailable service), 0 disables retries")
+	rootCmd.PersistentFlags().DurationVar(&retryMaxWait, "retry-max-wait", optimum.DefaultRetryMaxBackoff, "upper bound of the delay between retries")
 }
 
 var (
@@ -51,8 +79,87 @@ var (
 	exid    string
 	profile string
 	debug   bool
+	format  string
+
+	retries      int
+	retryMaxWait time.Duration
+)
+
+// output of the command in the requested format
+func output() (*common.Output, error) {
+	return common.NewOutput(format, os.Stdout)
+}
+
+// flags of list commands
+func withListFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&listFilter.Status, "status", "", "list only instances with given status (e.g. active, pending)")
+	cmd.Flags().StringVar(&listFilter.Prefix, "prefix", "", "list only instances which name starts with the prefix")
+	cmd.Flags().IntVar(&listFilter.Limit, "limit", 0, "maximum number of listed instances")
+}
+
+var listFilter common.Filter
+
+// flags of commands that spawn the job (e.g. create, commit)
+func withWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&jobWait, "wait", true, "block until the job is completed")
+	cmd.Flags().BoolVar(&jobNoWait, "no-wait", false, "submit the job and exit without waiting for completion")
+	cmd.Flags().DurationVar(&jobTimeout, "timeout", 0, "deadline for the job to complete (e.g. 30m), no deadline by default")
+	cmd.MarkFlagsMutuallyExclusive("wait", "no-wait")
+}
+
+var (
+	jobWait    bool
+	jobNoWait  bool
+	jobTimeout time.Duration
+)
+
+func waitFor() common.Wait {
+	return common.Wait{
+		Enabled: jobWait && !jobNoWait,
+		Timeout: jobTimeout,
+	}
+}
+
+// flag of commands that process keys or vectors by chunks
+func withChunkFlag(cmd *cobra.Command, chunk *int, usage string) {
+	cmd.Flags().IntVar(chunk, "chunk", 100, usage)
+	cmd.PreRunE = func(*cobra.Command, []string) error {
+		if *chunk <= 0 {
+			return fmt.Errorf("invalid chunk = %d, it shall be positive", *chunk)
+		}
+		return nil
+	}
+}
+
+// flags of query commands
+func withQueryFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&queryBatch, "batch", 100, "number of queries sent within one request")
+	cmd.Flags().IntVar(&queryConcurrency, "concurrency", 8, "number of concurrent queries if server does not support batches")
+	cmd.Flags().IntVar(&queryTune.K, "k", 0, "number of nearest neighbors to return (server default if not defined)")
+	cmd.Flags().IntVar(&queryTune.EfSearch, "ef-search", 0, "number of candidates evaluated during the search (server default if not defined)")
+	cmd.Flags().Float32Var(&queryTune.Distance, "max-distance", 0, "maximum distance to nearest neighbors (server default if not defined)")
+}
+
+var (
+	queryBatch       int
+	queryConcurrency int
+	queryTune        encoding.Tuning
 )
 
+// tuning of the query, the flags are used unless the query overrides them
+func queryTuning(t encoding.Tuning) encoding.Tuning {
+	if t.K == 0 {
+		t.K = queryTune.K
+	}
+	if t.EfSearch == 0 {
+		t.EfSearch = queryTune.EfSearch
+	}
+	if t.Distance == 0 {
+		t.Distance = queryTune.Distance
+	}
+	return t
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "optimum",
 	Short: "client for managing cloud data structures",
@@ -71,6 +178,11 @@ It is recommended to config environment variables for client usage:
   export HOST=https://example.com
   export ROLE=arn:aws:iam::000000000000:role/example-access-role
 
+The utility exits with non-zero status on errors:
+  1 - transport or any other error.
+  2 - the job (e.g. create, commit) is completed with failure.
+  3 - the job is not completed within the deadline (see --timeout).
+
 	`,
 	Run: root,
 }
@@ -99,7 +211,17 @@ func stackFromConfig(cfg aws.Config) (http.Stack, error) {
 		opts = append(opts, http.WithDebugPayload)
 	}
 
-	return http.New(opts...), nil
+	stack := http.New(opts...)
+	if retries > 0 {
+		stack = optimum.WithRetry(stack,
+			optimum.RetryPolicy{
+				MaxAttempts: retries + 1,
+				MaxInterval: retryMaxWait,
+			},
+		)
+	}
+
+	return stack, nil
 }
 
 func stackDefault() (http.Stack, error) {
@@ -9,20 +9,16 @@
 package opt
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"strings"
 
 	"github.com/fogfish/curie"
-	"github.com/fogfish/schemaorg"
 	"github.com/kshard/optimum"
+	"github.com/kshard/optimum/cmd/optimum/encoding"
 	"github.com/kshard/optimum/cmd/optimum/opt/common"
 	"github.com/kshard/optimum/sentences"
-	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 )
 
@@ -32,31 +28,71 @@ func init() {
 	rootCmd.AddCommand(textCmd)
 
 	textCmd.AddCommand(textListCmd)
+	withListFlags(textListCmd)
+
+	textCmd.AddCommand(textDescribeCmd)
 
 	textCmd.AddCommand(textCreateCmd)
 	textCreateCmd.Flags().StringVarP(&textOpts, "json", "j", "", "json config file")
+	withWaitFlags(textCreateCmd)
 
 	textCmd.AddCommand(textCommitCmd)
+	withWaitFlags(textCommitCmd)
 
 	textCmd.AddCommand(textUploadCmd)
 	textUploadCmd.Flags().IntVar(&textUploadBuf, "buf", 4, "upload buffer in MB (default 4MB)")
+	textUploadCmd.Flags().BoolVar(&textUploadResume, "resume", false, "resume interrupted upload from the checkpoint")
+	textUploadCmd.Flags().IntVar(&textUploadParallel, "parallel", 1, "number of chunks uploaded concurrently")
+	textUploadCmd.Flags().BoolVar(&textUploadEnvelope, "envelope", false, "send chunks within legacy json document instead of json lines")
+	withTextInputFlags(textUploadCmd)
 
 	textCmd.AddCommand(textStreamCmd)
-	textStreamCmd.Flags().IntVar(&textChunkSize, "chunk", 100, "streaming chunk size (default 10)")
+	withChunkFlag(textStreamCmd, &textStreamChunk, "number of text blocks streamed per request")
+	withTextInputFlags(textStreamCmd)
 
 	textCmd.AddCommand(textQueryCmd)
 	textQueryCmd.Flags().StringVarP(&textQueryFile, "file", "f", "", "text queries batch file")
 	textQueryCmd.Flags().IntVar(&textQuerySize, "size", 20, "size of the resultset")
+	textQueryCmd.Flags().MarkDeprecated("size", "use --k instead")
+	withQueryFlags(textQueryCmd)
+	textQueryCmd.Flags().StringVar(&textQueryFilter, "filter", "", "filter expression over metadata, e.g. 'keywords in (a, b)'")
+
+	textCmd.AddCommand(textGetCmd)
+	textGetCmd.Flags().StringVarP(&textGetFile, "file", "f", "", "file with identities of text, one per line")
+	withChunkFlag(textGetCmd, &textGetChunk, "number of ids fetched per request")
+
+	textCmd.AddCommand(textDeleteCmd)
+	withChunkFlag(textDeleteCmd, &textDeleteChunk, "number of ids deleted per request")
 
 	textCmd.AddCommand(textRemoveCmd)
 }
 
 var (
-	textOpts      string
-	textUploadBuf int
-	textChunkSize int
-	textQueryFile string
-	textQuerySize int
+	textOpts           string
+	textUploadBuf      int
+	textUploadResume   bool
+	textUploadParallel int
+	textUploadEnvelope bool
+	textStreamChunk    int
+	textGetChunk       int
+	textDeleteChunk    int
+	textQueryFile      string
+	textQuerySize      int
+	textQueryFilter    string
+	textGetFile        string
+)
+
+// flags of commands that read the dataset
+func withTextInputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&textFormat, "format", "", "format of the file: text or jsonl (derived from extension or content by default)")
+	cmd.Flags().BoolVar(&textSkipInvalid, "skip-invalid", false, "skip invalid lines, writing them to the reject file")
+	cmd.Flags().StringVar(&textRejectFile, "reject", "", "reject file (default <file>.rejected)")
+}
+
+var (
+	textFormat      string
+	textSkipInvalid bool
+	textRejectFile  string
 )
 
 var textCmd = &cobra.Command{
@@ -114,7 +150,42 @@ func textList(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	return common.List(optimum.New(cli, host), TYPE_TEXT)
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return common.List(optimum.New(cli, host), TYPE_TEXT, listFilter, func() common.Config { return &sentences.Config{} }, out)
+}
+
+//------------------------------------------------------------------------------
+
+var textDescribeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Describe instance of `text` data structure.",
+	Long:  common.AboutDescribe(TYPE_TEXT, ""),
+	Example: `
+optimum text describe -u $HOST -n example
+optimum text describe -u $HOST -r $ROLE -n example
+`,
+	SilenceUsage: true,
+	RunE:         textDescribe,
+}
+
+func textDescribe(cmd *cobra.Command, args []string) (err error) {
+	cli, err := stack()
+	if err != nil {
+		return err
+	}
+
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return common.Describe(optimum.New(cli, host), curie.New("%s:%s", TYPE_TEXT, name), &sentences.Config{}, out)
 }
 
 //------------------------------------------------------------------------------
@@ -176,7 +247,13 @@ func textCreate(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	return common.Create(optimum.New(cli, host), curie.New("%s:%s", TYPE_TEXT, name), textOpts)
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return common.Create(optimum.New(cli, host), curie.New("%s:%s", TYPE_TEXT, name), textOpts, &sentences.Config{}, waitFor(), out)
 }
 
 //------------------------------------------------------------------------------
@@ -188,6 +265,8 @@ var textCommitCmd = &cobra.Command{
 	Example: `
 optimum text commit -u $HOST -n example
 optimum text commit -u $HOST -r $ROLE -n example
+optimum text commit -u $HOST -n example --timeout 1h
+optimum text commit -u $HOST -n example --no-wait
 `,
 	SilenceUsage: true,
 	RunE:         textCommit,
@@ -199,7 +278,13 @@ func textCommit(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	return common.Commit(optimum.New(cli, host), curie.New("%s:%s", TYPE_TEXT, name))
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return common.Commit(optimum.New(cli, host), curie.New("%s:%s", TYPE_TEXT, name), waitFor(), out)
 }
 
 //------------------------------------------------------------------------------
@@ -209,7 +294,8 @@ var textUploadCmd = &cobra.Command{
 	Short: "Upload `text` datasets.",
 	Long: `
 Upload "text" dataset to server. It accepts either text or json data. The type
-of the file is determined by the extension.
+of the file is determined by the extension (.txt, .json, .jsonl or .ndjson) or
+defined by --format flag. Otherwise, it is sniffed from the first line.
 
 Text files (.txt)
 
@@ -229,17 +315,23 @@ be indexed:
 
 The full schema of json object is following
   {
+    "id": "...",         // optional identity of the text, used to delete it.
     "text": "...",       // short text block less than 4KB.
     "isPartOf": "...",   // URL of the original doc from which the text is derived.
     "headline": ["..."], // headline(s) of the text.
     "keywords": ["..."], // relevant keywords for the text.
     "links": ["..."]     // external URIs associated with the text. 
   }
-
-`,
+` + common.AboutReject(TYPE_TEXT) + common.AboutInput() + common.AboutCheckpoint(TYPE_TEXT),
 	Example: `
 optimum text upload -u $HOST -n example path/to/data.txt
 optimum text upload -u $HOST -r $ROLE -n example path/to/data.json
+optimum text upload -u $HOST -n example --resume path/to/data.json
+optimum text upload -u $HOST -n example --parallel 8 path/to/data.json
+optimum text upload -u $HOST -n example --skip-invalid path/to/data.json
+optimum text upload -u $HOST -n example --envelope path/to/data.json
+optimum text upload -u $HOST -n example path/to/data.jsonl.gz
+cat path/to/data.ndjson | optimum text upload -u $HOST -n example -
 `,
 	SilenceUsage: true,
 	Args:         cobra.ExactArgs(1),
@@ -247,14 +339,13 @@ optimum text upload -u $HOST -r $ROLE -n example path/to/data.json
 }
 
 func textUpload(cmd *cobra.Command, args []string) (err error) {
-	isJson := strings.HasSuffix(args[0], "json")
-	fd, err := os.Open(args[0])
+	fd, err := common.OpenInput(args[0], "==> uploading")
 	if err != nil {
 		return err
 	}
 	defer fd.Close()
 
-	fi, err := fd.Stat()
+	format, err := encoding.SentencesFormatOf(fd.Reader, fd.File, textFormat)
 	if err != nil {
 		return err
 	}
@@ -264,43 +355,60 @@ func textUpload(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	stream := sentences.NewWriter(cli, host, curie.New("%s:%s", TYPE_TEXT, name), hnswUploadBuf*1024*1024)
+	cask := curie.New("%s:%s", TYPE_TEXT, name)
+	ckpt, err := common.NewCheckpoint(cask, args[0], fd.Size, textUploadResume)
+	if err != nil {
+		return err
+	}
+
+	rejects, err := common.NewRejects(args[0], textRejectFile, textSkipInvalid, ckpt.Rejected)
+	if err != nil {
+		return err
+	}
+	defer rejects.Close()
+
+	opts := []sentences.Option{sentences.WithParallel(textUploadParallel)}
+	if textUploadEnvelope {
+		opts = append(opts, sentences.WithEnvelope())
+	}
+
+	stream := sentences.NewWriter(cli, host, cask, hnswUploadBuf*1024*1024, opts...)
 
-	r := io.TeeReader(fd,
-		progressbar.DefaultBytes(
-			fi.Size(),
-			"==> uploading",
-		),
-	)
+	scanner := encoding.NewSentences(fd, format == encoding.FORMAT_JSONL)
+
+	// the dataset is read from the checkpoint
+	if err := scanner.Skip(ckpt.Offset); err != nil {
+		return err
+	}
 
-	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		text := scanner.Text()
-
-		var sentence sentences.Sentence
-		if isJson {
-			json.Unmarshal([]byte(text), &sentence)
-		} else {
-			sentence = sentences.Sentence{
-				Text: schemaorg.Text(text),
+		if err := scanner.Invalid(); err != nil {
+			if err := rejects.Reject(scanner.Bytes(), scanner.Offset(), err); err != nil {
+				return err
 			}
+			continue
 		}
 
-		err := stream.Write(context.Background(), sentence)
+		err := stream.WriteAt(context.Background(), scanner.Sentence(), scanner.Offset())
 		if err != nil {
 			return err
 		}
+		rejects.Accept()
+
+		if err := ckpt.Save(stream.Offset(), rejects.Size(stream.Offset())); err != nil {
+			return err
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		return err
 	}
 
-	if err := stream.Sync(context.Background()); err != nil {
+	if err := stream.Close(context.Background()); err != nil {
 		return err
 	}
 
-	return nil
+	return ckpt.Remove()
 }
 
 //------------------------------------------------------------------------------
@@ -310,7 +418,8 @@ var textStreamCmd = &cobra.Command{
 	Short: "Stream `text` datasets.",
 	Long: `
 Upload "text" dataset to server. It accepts either text or json data. The type
-of the file is determined by the extension.
+of the file is determined by the extension (.txt, .json, .jsonl or .ndjson) or
+defined by --format flag. Otherwise, it is sniffed from the first line.
 
 Text files (.txt)
 
@@ -330,17 +439,19 @@ be indexed:
 
 The full schema of json object is following
   {
+    "id": "...",         // optional identity of the text, used to delete it.
     "text": "...",       // short text block less than 4KB.
     "isPartOf": "...",   // URL of the original doc from which the text is derived.
     "headline": ["..."], // headline(s) of the text.
 		"keywords": ["..."], // relevant keywords for the text.
 		"links": ["..."]     // external URIs associated with the text. 
   }
-
-`,
+` + common.AboutReject(TYPE_TEXT) + common.AboutInput(),
 	Example: `
 optimum text stream -u $HOST -n example path/to/data.json
 optimum text stream -u $HOST -r $ROLE -n example path/to/data.txt
+optimum text stream -u $HOST -n example --skip-invalid --reject bad.json path/to/data.json
+optimum text stream -u $HOST -n example --format text - < path/to/data.log
 `,
 	SilenceUsage: true,
 	Args:         cobra.ExactArgs(1),
@@ -348,14 +459,13 @@ optimum text stream -u $HOST -r $ROLE -n example path/to/data.txt
 }
 
 func textStream(cmd *cobra.Command, args []string) (err error) {
-	isJson := strings.HasSuffix(args[0], "json")
-	fd, err := os.Open(args[0])
+	fd, err := common.OpenInput(args[0], "==> uploading")
 	if err != nil {
 		return err
 	}
 	defer fd.Close()
 
-	fi, err := fd.Stat()
+	format, err := encoding.SentencesFormatOf(fd.Reader, fd.File, textFormat)
 	if err != nil {
 		return err
 	}
@@ -367,29 +477,24 @@ func textStream(cmd *cobra.Command, args []string) (err error) {
 
 	api := sentences.New(cli, host)
 
-	r := io.TeeReader(fd,
-		progressbar.DefaultBytes(
-			fi.Size(),
-			"==> uploading",
-		),
-	)
+	rejects, err := common.NewRejects(args[0], textRejectFile, textSkipInvalid, 0)
+	if err != nil {
+		return err
+	}
+	defer rejects.Close()
 
-	scanner := bufio.NewScanner(r)
+	scanner := encoding.NewSentences(fd, format == encoding.FORMAT_JSONL)
 	for scanner.Scan() {
 		bag := make([]sentences.Sentence, 0)
-		for i, has := 0, true; i < textChunkSize && has; i, has = i+1, scanner.Scan() {
-			text := scanner.Text()
-
-			var sentence sentences.Sentence
-			if isJson {
-				json.Unmarshal([]byte(text), &sentence)
-			} else {
-				sentence = sentences.Sentence{
-					Text: schemaorg.Text(text),
+		for i, has := 0, true; i < textStreamChunk && has; i, has = i+1, scanner.Scan() {
+			if err := scanner.Invalid(); err != nil {
+				if err := rejects.Reject(scanner.Bytes(), scanner.Offset(), err); err != nil {
+					return err
 				}
+				continue
 			}
 
-			bag = append(bag, sentence)
+			bag = append(bag, scanner.Sentence())
 		}
 
 		if len(bag) > 0 {
@@ -397,9 +502,15 @@ func textStream(cmd *cobra.Command, args []string) (err error) {
 			if err != nil {
 				return err
 			}
+
+			rejects.Accepted += len(bag)
 		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -416,11 +527,34 @@ each line is query to evaluate:
   it's simply a fantasy to amuse myself; a plaything!
 
 The file format is identical to the upload textual and can be re-used as is.
-`,
+Use --k, --ef-search and --max-distance flags to tune the query. The jsonl file
+(.json or .jsonl) overrides them per query, the tuning attributes are optional:
+
+  {"text": "under the roof", "k": 10, "efSearch": 200}
+  {"text": "a plaything", "distance": 0.35}
+
+Queries of the file are sent in batches (--batch), the client falls back to
+concurrent queries (--concurrency) if server does not support batches.
+
+Use --filter flag to restrict the retrieval by metadata of text. The filter is
+boolean expression (and, or, not, parentheses) over conditions:
+
+  isPartOf = "https://example.com/a"      equality;
+  keywords in (travel, "new york")        set membership;
+  isPartOf ^= "https://example.com/"      prefix, only supported by isPartOf.
+
+Fields are isPartOf, keywords and headline. List fields (keywords, headline)
+match if any of elements matches.
+` + common.AboutInput(),
 	Example: `
 optimum text query -u $HOST -n example -f path/to/query.txt
 optimum text query -u $HOST -r $ROLE -n example -f path/to/query.txt
+optimum text query -u $HOST -n example -f - < path/to/query.txt
+optimum text query -u $HOST -n example --concurrency 16 -f path/to/query.txt
 optimum text query -u $HOST -n example "under the roof"
+optimum text query -u $HOST -n example --k 10 --ef-search 200 "under the roof"
+optimum text query -u $HOST -n example -f path/to/query.jsonl
+optimum text query -u $HOST -n example --filter 'isPartOf ^= "https://example.com/" and not keywords = draft' "under the roof"
 `,
 	SilenceUsage: true,
 	RunE:         textQuery,
@@ -441,14 +575,29 @@ func textQuery(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
 	api := sentences.New(cli, host)
 
-	query := sentences.Query{Text: q, K: textQuerySize}
+	filter, err := textFilter()
+	if err != nil {
+		return err
+	}
+
+	query := textQueryOf(q, encoding.Tuning{}, filter)
 	rs, err := api.Query(context.Background(), curie.New("%s:%s", TYPE_TEXT, name), query)
 	if err != nil {
 		return err
 	}
 
+	if !out.Table() {
+		return out.Write(textQueryResult{Query: query.Text, Result: rs})
+	}
+
 	for _, hit := range rs.Hits {
 		fmt.Printf("%1.4f : %32s \n", hit.Rank, hit.Text)
 	}
@@ -456,8 +605,43 @@ func textQuery(cmd *cobra.Command, args []string) (err error) {
 	return nil
 }
 
+// query of the text, the tuning is overridden per query
+func textQueryOf(text string, tuning encoding.Tuning, filter *sentences.Filter) sentences.Query {
+	tuning = queryTuning(tuning)
+	if tuning.K == 0 {
+		tuning.K = textQuerySize
+	}
+
+	return sentences.Query{
+		Text:     text,
+		K:        tuning.K,
+		EfSearch: tuning.EfSearch,
+		Distance: tuning.Distance,
+		Filter:   filter,
+	}
+}
+
+// filter of query, it is defined by --filter flag
+func textFilter() (*sentences.Filter, error) {
+	if textQueryFilter == "" {
+		return nil, nil
+	}
+
+	filter, err := sentences.ParseFilter(textQueryFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filter, nil
+}
+
 func textQueryWithFile(cmd *cobra.Command, args []string) (err error) {
-	fd, err := os.Open(textQueryFile)
+	filter, err := textFilter()
+	if err != nil {
+		return err
+	}
+
+	fd, err := common.OpenInput(textQueryFile, "")
 	if err != nil {
 		return err
 	}
@@ -468,30 +652,251 @@ func textQueryWithFile(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	api := sentences.New(cli, host)
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	format, err := encoding.SentencesFormatOf(fd.Reader, fd.File, "")
+	if err != nil {
+		return err
+	}
+
+	api := sentences.New(cli, host, sentences.WithConcurrency(queryConcurrency))
+	cask := curie.New("%s:%s", TYPE_TEXT, name)
 
 	n := 1
-	scanner := bufio.NewScanner(fd)
+	seq := make([]sentences.Query, 0, queryBatch)
+	flush := func() error {
+		rs, err := api.QueryBatch(context.Background(), cask, seq)
+		if err != nil {
+			return err
+		}
+
+		for i := range rs {
+			if err := textQueryWrite(out, n, seq[i], &rs[i]); err != nil {
+				return err
+			}
+			n++
+		}
+
+		seq = seq[:0]
+		return nil
+	}
+
+	scanner := encoding.NewQueries(fd, format == encoding.FORMAT_JSONL)
 	for scanner.Scan() {
-		query := sentences.Query{Text: scanner.Text(), K: textQuerySize}
-		rs, err := api.Query(context.Background(), curie.New("%s:%s", TYPE_TEXT, name), query)
+		seq = append(seq, textQueryOf(scanner.Text(), scanner.Tuning(), filter))
+		if len(seq) < queryBatch {
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+func textQueryWrite(out *common.Output, n int, query sentences.Query, rs *sentences.Result) error {
+	if !out.Table() {
+		return out.Write(textQueryResult{Query: query.Text, Result: rs})
+	}
+
+	fmt.Printf("\nQuery %d (took %s) | %s (vsn %s, size %d)\n", n, rs.Took, rs.Source.Cask, rs.Source.Version, rs.Source.Size)
+	fmt.Printf("  > %s\n", query.Text)
+	for _, hit := range rs.Hits {
+		fmt.Printf("  %f : %32s \n", hit.Rank, hit.Text)
+	}
+
+	return nil
+}
+
+// result of the query, it is associated with query text
+type textQueryResult struct {
+	Query string `json:"query"`
+	*sentences.Result
+}
+
+//------------------------------------------------------------------------------
+
+var textGetCmd = &cobra.Command{
+	Use:   "get [id ...]",
+	Short: "Get text stored in `text` instance by identity.",
+	Long: `
+Get text blocks stored in "text" data structure instance, it is useful to
+inspect the data (e.g. debug ranking). Identities are either given as arguments
+or read from the file, where each line is identity of text block.
+
+Text blocks are fetched in batches, identities that are not found are reported
+at the end.
+` + common.AboutInput(),
+	Example: `
+optimum text get -u $HOST -n example 5d41402abc4b2a76b9719d911017c592
+optimum text get -u $HOST -n example -f path/to/ids.txt -o jsonl
+`,
+	SilenceUsage: true,
+	RunE:         textGet,
+}
+
+func textGet(cmd *cobra.Command, args []string) (err error) {
+	ids, err := textGetIDs(args)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return fmt.Errorf("ids are not defined")
+	}
+
+	cli, err := stack()
+	if err != nil {
+		return err
+	}
+
+	out, err := output()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	api := sentences.New(cli, host)
+	cask := curie.New("%s:%s", TYPE_TEXT, name)
+
+	if len(ids) == 1 {
+		v, err := api.Get(context.Background(), cask, ids[0])
+		if err != nil {
+			return err
+		}
+
+		return textGetWrite(out, *v)
+	}
+
+	n := 0
+	for at := 0; at < len(ids); at += textGetChunk {
+		bag, err := api.MultiGet(context.Background(), cask, ids[at:min(at+textGetChunk, len(ids))])
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("\nQuery %d (took %s) | %s (vsn %s, size %d)\n", n, rs.Took, rs.Source.Cask, rs.Source.Version, rs.Source.Size)
-		fmt.Printf("  > %s\n", query.Text)
-		for _, hit := range rs.Hits {
-			fmt.Printf("  %f : %32s \n", hit.Rank, hit.Text)
+		for _, v := range bag {
+			if err := textGetWrite(out, v); err != nil {
+				return err
+			}
 		}
+		n += len(bag)
+	}
 
-		n++
+	if n < len(ids) {
+		fmt.Fprintf(os.Stderr, "==> %d of %d ids are not found\n", len(ids)-n, len(ids))
+	}
+
+	return nil
+}
+
+// identities are read from arguments and the file
+func textGetIDs(args []string) ([]string, error) {
+	ids := append([]string{}, args...)
+	if textGetFile == "" {
+		return ids, nil
+	}
+
+	fd, err := common.OpenInput(textGetFile, "")
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	scanner := encoding.NewKeys(fd)
+	for scanner.Scan() {
+		ids = append(ids, scanner.Text())
+	}
+
+	return ids, scanner.Err()
+}
+
+func textGetWrite(out *common.Output, v sentences.Sentence) error {
+	if !out.Table() {
+		return out.Write(v)
+	}
+
+	fmt.Printf("%s | %s\n", v.ID, v.IsPartOf)
+	fmt.Printf("  > %s\n", v.Text)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var textDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete text from `text` instance by identity.",
+	Long: `
+Delete text blocks from "text" data structure instance. It accepts textual file,
+where each line is identity of text block (the "id" attribute of json object).
+Blank lines and comments (lines starting with #) are skipped:
+
+  5d41402abc4b2a76b9719d911017c592
+  7d793037a0760186574b0282f2f435e7
+
+Text blocks are deleted immediately, without commit.
+` + common.AboutInput(),
+	Example: `
+optimum text delete -u $HOST -n example path/to/ids.txt
+optimum text delete -u $HOST -r $ROLE -n example path/to/ids.txt
+echo "5d41402abc4b2a76b9719d911017c592" | optimum text delete -u $HOST -n example -
+`,
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(1),
+	RunE:         textDelete,
+}
+
+func textDelete(cmd *cobra.Command, args []string) (err error) {
+	fd, err := common.OpenInput(args[0], "==> deleting")
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	cli, err := stack()
+	if err != nil {
+		return err
+	}
+
+	api := sentences.New(cli, host)
+	cask := curie.New("%s:%s", TYPE_TEXT, name)
+
+	n := 0
+	bag := make([]string, 0, textDeleteChunk)
+	scanner := encoding.NewKeys(fd)
+	for scanner.Scan() {
+		bag = append(bag, scanner.Text())
+		if len(bag) < textDeleteChunk {
+			continue
+		}
+
+		if err := api.Delete(context.Background(), cask, bag); err != nil {
+			return err
+		}
+		n += len(bag)
+		bag = bag[:0]
 	}
 
 	if err := scanner.Err(); err != nil {
 		return err
 	}
 
+	if err := api.Delete(context.Background(), cask, bag); err != nil {
+		return err
+	}
+	n += len(bag)
+
+	fmt.Printf("==> deleted %d text blocks\n", n)
 	return nil
 }
 
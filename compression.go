@@ -0,0 +1,72 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package optimum
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression of the payload sent to server, the value is used as
+// Content-Encoding header.
+type Compression string
+
+const (
+	Identity Compression = ""
+	Gzip     Compression = "gzip"
+	Zstd     Compression = "zstd"
+)
+
+// NewWriter wraps the writer with compression. The level is specific to
+// the algorithm (e.g. 1 - 9 for gzip, 1 - 22 for zstd), zero value is
+// the default level of the algorithm.
+func (c Compression) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	switch c {
+	case Identity:
+		return nopCloser{w}, nil
+	case Gzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case Zstd:
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	default:
+		return nil, fmt.Errorf("compression %q is not supported, use one of gzip or zstd", string(c))
+	}
+}
+
+// NewReader wraps the reader with decompression.
+func (c Compression) NewReader(r io.Reader) (io.ReadCloser, error) {
+	switch c {
+	case Identity:
+		return io.NopCloser(r), nil
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("compression %q is not supported, use one of gzip or zstd", string(c))
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
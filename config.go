@@ -0,0 +1,40 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package optimum
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigError reports every constraint violated by the configuration of
+// data structure instance.
+type ConfigError struct {
+	Kind       string
+	Violations []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s config: %s", e.Kind, strings.Join(e.Violations, "; "))
+}
+
+// Check appends violation to the error if the condition is not met
+func (e *ConfigError) Check(ok bool, format string, args ...any) {
+	if !ok {
+		e.Violations = append(e.Violations, fmt.Sprintf(format, args...))
+	}
+}
+
+// Err returns nil if no constraints are violated
+func (e *ConfigError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,175 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package optimum
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogfish/gurl/v2/http"
+)
+
+// Sentinel errors, use errors.Is to match Error returned by clients
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrThrottled  = errors.New("throttled")
+)
+
+// Error is returned by clients when server responds with unexpected status.
+// The error details are decoded from problem details (RFC 7807) if the server
+// provides them.
+type Error struct {
+	// HTTP status code of the response
+	StatusCode int
+
+	// Machine readable code (problem type) of the error, if any
+	Code string
+
+	// Human readable explanation of the error
+	Message string
+
+	// Identity of the request, use it for troubleshooting with operator
+	RequestID string
+
+	// The request might succeed if it is retried later
+	Retryable bool
+
+	// Delay before the retry, if it is advertised by the server
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString(e.Message)
+	sb.WriteString(" (status ")
+	sb.WriteString(strconv.Itoa(e.StatusCode))
+	if e.Code != "" {
+		sb.WriteString(", code ")
+		sb.WriteString(e.Code)
+	}
+	if e.RequestID != "" {
+		sb.WriteString(", request ")
+		sb.WriteString(e.RequestID)
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == nethttp.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == nethttp.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == nethttp.StatusBadRequest ||
+			e.StatusCode == nethttp.StatusUnprocessableEntity
+	case ErrThrottled:
+		return e.StatusCode == nethttp.StatusTooManyRequests
+	default:
+		return false
+	}
+}
+
+// Code is a mandatory statement to match expected HTTP Status Code against
+// received one, it is drop-in replacement of ƒ.Status.* arrows. The execution
+// fails with Error decoded from the response if service responds with other
+// status code.
+func Code(code ...http.StatusCode) http.Arrow {
+	return func(cat *http.Context) error {
+		if err := cat.Unsafe(); err != nil {
+			return err
+		}
+
+		status := cat.Response.StatusCode
+		for _, c := range code {
+			if c.StatusCode() == status {
+				return nil
+			}
+		}
+
+		return decodeError(cat)
+	}
+}
+
+// problem details (RFC 7807) and its common variants
+type problem struct {
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// the size of error body to consume
+const maxErrorBody = 64 * 1024
+
+func decodeError(cat *http.Context) error {
+	rsp := cat.Response
+	err := &Error{
+		StatusCode: rsp.StatusCode,
+		RequestID:  requestID(rsp.Header.Get),
+	}
+
+	var p problem
+	if body, e := io.ReadAll(io.LimitReader(rsp.Body, maxErrorBody)); e == nil {
+		if json.Unmarshal(body, &p) != nil {
+			p.Detail = strings.TrimSpace(string(body))
+		}
+	}
+
+	err.Code = first(p.Code, p.Type)
+	err.Message = first(p.Detail, p.Message, p.Title, nethttp.StatusText(rsp.StatusCode))
+	if err.RequestID == "" {
+		err.RequestID = p.RequestID
+	}
+
+	switch rsp.StatusCode {
+	case nethttp.StatusTooManyRequests,
+		nethttp.StatusBadGateway,
+		nethttp.StatusServiceUnavailable,
+		nethttp.StatusGatewayTimeout:
+		err.Retryable = true
+	}
+
+	if after := rsp.Header.Get("Retry-After"); after != "" {
+		err.Retryable = true
+		err.RetryAfter = retryAfter(after)
+	}
+
+	return err
+}
+
+func requestID(header func(string) string) string {
+	for _, h := range []string{"X-Request-Id", "X-Amzn-Requestid", "X-Amz-Request-Id", "Apigw-Requestid"} {
+		if id := header(h); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func first(seq ...string) string {
+	for _, x := range seq {
+		if x != "" {
+			return x
+		}
+	}
+	return ""
+}
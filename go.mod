@@ -1,11 +1,12 @@
 module github.com/kshard/optimum
 
-go 1.22.2
+go 1.23
 
 require (
 	github.com/fogfish/curie v1.8.2
 	github.com/fogfish/gurl/v2 v2.9.0
 	github.com/fogfish/schemaorg v1.22.0
+	github.com/klauspost/compress v1.18.0
 	github.com/kshard/wreck v0.0.2
 )
 
@@ -0,0 +1,94 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+// Package batch implements client-side fallback of batch requests.
+package batch
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"sync"
+
+	"github.com/kshard/optimum"
+)
+
+// Unsupported is true if the error indicates that the server does not
+// support the batch endpoint.
+func Unsupported(err error) bool {
+	var e *optimum.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	return e.StatusCode == nethttp.StatusMethodNotAllowed ||
+		e.StatusCode == nethttp.StatusNotImplemented
+}
+
+// Ambiguous is true if the error does not distinguish the missing batch
+// endpoint from the missing resource (e.g. not found). The batch endpoint is
+// unsupported only if the fallback succeeds.
+func Ambiguous(err error) bool {
+	return errors.Is(err, optimum.ErrNotFound)
+}
+
+// Map applies the function to each input, at most n functions are executed
+// concurrently. Results are aligned with the input. The first error cancels
+// the remaining executions.
+func Map[A, B any](ctx context.Context, n int, seq []A, f func(context.Context, A) (B, error)) ([]B, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		fail error
+	)
+
+	out := make([]B, len(seq))
+	inflight := make(chan struct{}, max(n, 1))
+
+	for i, x := range seq {
+		select {
+		case inflight <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-inflight }()
+
+			val, err := f(ctx, x)
+			if err != nil {
+				once.Do(func() {
+					fail = err
+					cancel()
+				})
+				return
+			}
+			out[i] = val
+		}()
+	}
+
+	wg.Wait()
+
+	if fail != nil {
+		return nil, fail
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
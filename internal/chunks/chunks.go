@@ -0,0 +1,125 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+// Package chunks implements the pipeline of chunks uploaded by writers.
+package chunks
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline sends chunks concurrently, the number of chunks in-flight is
+// bounded. Chunks are acknowledged in the order of sending, the offset
+// of input is advanced only if all preceding chunks are succeeded and
+// the error of the earliest failed chunk is reported.
+type Pipeline struct {
+	inflight chan struct{}
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	seq    int64
+	next   int64
+	done   map[int64]ack
+	offset int64
+	err    error
+}
+
+type ack struct {
+	offset int64
+	err    error
+}
+
+// New creates pipeline with given number of chunks in-flight, the value
+// less than 2 makes the pipeline sequential.
+func New(parallel int) *Pipeline {
+	return &Pipeline{
+		inflight: make(chan struct{}, max(parallel, 1)),
+		done:     map[int64]ack{},
+	}
+}
+
+// Send the chunk, the offset is the position of input after the chunk. It
+// blocks while the pipeline is full. The error of earlier chunks is returned
+// and no more chunks are sent after the failure.
+func (p *Pipeline) Send(ctx context.Context, offset int64, send func(context.Context) error) error {
+	if err := p.Err(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	seq := p.seq
+	p.seq++
+	p.mu.Unlock()
+
+	if cap(p.inflight) == 1 {
+		p.ack(seq, ack{offset: offset, err: send(ctx)})
+		return p.Err()
+	}
+
+	select {
+	case p.inflight <- struct{}{}:
+	case <-ctx.Done():
+		p.ack(seq, ack{offset: offset, err: ctx.Err()})
+		return p.Err()
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.inflight }()
+
+		p.ack(seq, ack{offset: offset, err: send(ctx)})
+	}()
+
+	return nil
+}
+
+func (p *Pipeline) ack(seq int64, a ack) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done[seq] = a
+	for p.err == nil {
+		x, has := p.done[p.next]
+		if !has {
+			return
+		}
+		delete(p.done, p.next)
+
+		if x.err != nil {
+			p.err = x.err
+			return
+		}
+
+		p.offset = x.offset
+		p.next++
+	}
+}
+
+// Wait for chunks in-flight, it returns the error of the earliest failed chunk.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	return p.Err()
+}
+
+// Err returns the error of the earliest failed chunk
+func (p *Pipeline) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+// Offset of input acknowledged by all chunks sent so far
+func (p *Pipeline) Offset() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.offset
+}
@@ -0,0 +1,215 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	errA = errors.New("a")
+	errB = errors.New("b")
+)
+
+type chunk struct {
+	delay time.Duration
+	err   error
+}
+
+// offset of input after i-th chunk
+func offsetOf(i int) int64 { return int64(i+1) * 10 }
+
+func TestPipeline(t *testing.T) {
+	ms := time.Millisecond
+
+	for _, tt := range []struct {
+		name     string
+		parallel int
+		chunks   []chunk
+		offset   int64
+		err      error
+	}{
+		{
+			name:     "out of order",
+			parallel: 4,
+			chunks:   []chunk{{30 * ms, nil}, {10 * ms, nil}, {20 * ms, nil}, {0, nil}, {5 * ms, nil}},
+			offset:   offsetOf(4),
+		},
+		{
+			name:     "failure",
+			parallel: 4,
+			chunks:   []chunk{{10 * ms, nil}, {30 * ms, errA}, {0, nil}, {0, nil}},
+			offset:   offsetOf(0),
+			err:      errA,
+		},
+		{
+			name:     "first chunk fails",
+			parallel: 4,
+			chunks:   []chunk{{20 * ms, errA}, {0, nil}, {0, nil}},
+			offset:   0,
+			err:      errA,
+		},
+		{
+			name:     "earliest failure",
+			parallel: 4,
+			chunks:   []chunk{{0, nil}, {30 * ms, errA}, {0, errB}, {10 * ms, nil}},
+			offset:   offsetOf(0),
+			err:      errA,
+		},
+		{
+			name:     "sequential",
+			parallel: 1,
+			chunks:   []chunk{{0, nil}, {ms, nil}, {0, errA}},
+			offset:   offsetOf(1),
+			err:      errA,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.parallel)
+
+			var mu sync.Mutex
+			completed := make([]bool, len(tt.chunks))
+
+			// the offset never passes the chunk which is not completed
+			stop := make(chan struct{})
+			monitor := make(chan error)
+			go func() {
+				defer close(monitor)
+				for {
+					offset := p.Offset()
+					violated := false
+					mu.Lock()
+					for i := range tt.chunks {
+						if offsetOf(i) <= offset && (!completed[i] || tt.chunks[i].err != nil) {
+							violated = true
+						}
+					}
+					mu.Unlock()
+
+					if violated {
+						monitor <- errors.New("offset is acknowledged out of order")
+						return
+					}
+
+					select {
+					case <-stop:
+						return
+					case <-time.After(100 * time.Microsecond):
+					}
+				}
+			}()
+
+			for i, c := range tt.chunks {
+				err := p.Send(context.Background(), offsetOf(i), func(context.Context) error {
+					time.Sleep(c.delay)
+					mu.Lock()
+					completed[i] = true
+					mu.Unlock()
+					return c.err
+				})
+				if err != nil {
+					if !errors.Is(err, tt.err) {
+						t.Errorf("unexpected error of send %v", err)
+					}
+					break
+				}
+			}
+
+			err := p.Wait()
+			close(stop)
+			for failed := range monitor {
+				t.Error(failed)
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("unexpected error %v, expected %v", err, tt.err)
+			}
+
+			if p.Offset() != tt.offset {
+				t.Errorf("unexpected offset %d, expected %d", p.Offset(), tt.offset)
+			}
+
+			// wait is repeatable after failure
+			if err := p.Wait(); !errors.Is(err, tt.err) {
+				t.Errorf("unexpected error of repeated wait %v", err)
+			}
+		})
+	}
+}
+
+func TestPipelineShortCircuit(t *testing.T) {
+	for _, parallel := range []int{1, 4} {
+		p := New(parallel)
+
+		if err := p.Send(context.Background(), 10, func(context.Context) error { return errA }); err != nil && !errors.Is(err, errA) {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		if err := p.Wait(); !errors.Is(err, errA) {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		if err := p.Err(); !errors.Is(err, errA) {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		sent := false
+		err := p.Send(context.Background(), 20, func(context.Context) error {
+			sent = true
+			return nil
+		})
+		if !errors.Is(err, errA) || sent {
+			t.Errorf("chunk is sent after failure")
+		}
+
+		if err := p.Wait(); !errors.Is(err, errA) || p.Offset() != 0 {
+			t.Errorf("unexpected state after failure %v at %d", err, p.Offset())
+		}
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	p := New(2)
+
+	block := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		if err := p.Send(context.Background(), offsetOf(i), func(context.Context) error {
+			<-block
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// pipeline is full, the send is cancelled while it waits
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	sent := false
+	err := p.Send(ctx, offsetOf(2), func(context.Context) error {
+		sent = true
+		return nil
+	})
+	if err != nil || sent {
+		t.Errorf("unexpected send of chunk %v", err)
+	}
+
+	close(block)
+	if err := p.Wait(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if p.Offset() != offsetOf(1) {
+		t.Errorf("unexpected offset %d", p.Offset())
+	}
+}
@@ -10,10 +10,10 @@ package optimum
 
 import (
 	"context"
+	"iter"
 
 	"github.com/fogfish/curie"
 	"github.com/fogfish/gurl/v2/http"
-	ƒ "github.com/fogfish/gurl/v2/http/recv"
 	ø "github.com/fogfish/gurl/v2/http/send"
 	"github.com/fogfish/schemaorg"
 )
@@ -32,19 +32,77 @@ func New(stack http.Stack, host string) *Client {
 	return cli
 }
 
+// Casks lists the first page of data structure instances of given type.
+// Use Instances.Next cursor with CasksAfter to fetch following pages or
+// iterate over all instances with CasksSeq.
 func (api *Client) Casks(ctx context.Context, schema string) (*Instances, error) {
+	return api.CasksAfter(ctx, schema, "")
+}
+
+// CasksAfter lists the page of data structure instances of given type,
+// starting after the cursor.
+func (api *Client) CasksAfter(ctx context.Context, schema string, cursor string) (*Instances, error) {
+	req := []http.Arrow{
+		ø.URI("%s/ds/%s", api.host, schema),
+	}
+	if cursor != "" {
+		req = append(req, ø.Param("cursor", cursor))
+	}
+	req = append(req,
+		ø.Accept.JSON,
+
+		Code(http.StatusOK),
+	)
+
 	return http.IO[Instances](
+		api.WithContext(ctx),
+		http.GET(req...),
+	)
+}
+
+// CasksSeq iterates over all data structure instances of given type, pages
+// are fetched transparently. The iteration stops after the first error.
+func (api *Client) CasksSeq(ctx context.Context, schema string) iter.Seq2[Instance, error] {
+	return func(yield func(Instance, error) bool) {
+		cursor := ""
+		for {
+			seq, err := api.CasksAfter(ctx, schema, cursor)
+			if err != nil {
+				yield(Instance{}, err)
+				return
+			}
+
+			for _, x := range seq.Items {
+				if !yield(x, nil) {
+					return
+				}
+			}
+
+			if seq.Next == "" || seq.Next == cursor {
+				return
+			}
+			cursor = seq.Next
+		}
+	}
+}
+
+// Cask fetches the data structure instance, it returns Error matching
+// ErrNotFound if the instance does not exist.
+func (api *Client) Cask(ctx context.Context, cask curie.IRI) (*Instance, error) {
+	return http.IO[Instance](
 		api.WithContext(ctx),
 		http.GET(
-			ø.URI("%s/ds/%s", api.host, schema),
+			ø.URI("%s/ds/%s/%s", api.host, curie.Prefix(cask), curie.Reference(cask)),
 			ø.Accept.JSON,
 
-			ƒ.Status.OK,
+			Code(http.StatusOK),
 		),
 	)
 }
 
-func (api *Client) Create(ctx context.Context, cask curie.IRI, opts map[string]any) (*Created, error) {
+// Create new instance of data structure. The opts is either typed config of
+// the data structure (e.g. surface.Config) or any value encodable to JSON.
+func (api *Client) Create(ctx context.Context, cask curie.IRI, opts any) (*Created, error) {
 	return http.IO[Created](
 		api.WithContext(ctx),
 		http.POST(
@@ -56,7 +114,7 @@ func (api *Client) Create(ctx context.Context, cask curie.IRI, opts map[string]a
 				Opts: opts,
 			}),
 
-			ƒ.Status.Accepted,
+			Code(http.StatusAccepted),
 		),
 	)
 }
@@ -70,7 +128,7 @@ func (api *Client) Commit(ctx context.Context, cask curie.IRI) (*Committed, erro
 			ø.ContentType.JSON,
 			ø.Send(commit{Cursor: "latest"}),
 
-			ƒ.Status.Accepted,
+			Code(http.StatusAccepted),
 		),
 	)
 
@@ -83,7 +141,7 @@ func (api *Client) Status(ctx context.Context, job schemaorg.Url) (*JobStatus, e
 			ø.URI("%s%s", api.host, ø.Path(job)),
 			ø.Accept.JSON,
 
-			ƒ.Status.OK,
+			Code(http.StatusOK),
 		),
 	)
 }
@@ -94,7 +152,7 @@ func (api *Client) Remove(ctx context.Context, cask curie.IRI) error {
 			ø.URI("%s/ds/%s/%s", api.host, curie.Prefix(cask), curie.Reference(cask)),
 			ø.Accept.JSON,
 
-			ƒ.Status.Accepted,
+			Code(http.StatusAccepted),
 		),
 	)
 }
@@ -0,0 +1,207 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package optimum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	nethttp "net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fogfish/gurl/v2/http"
+)
+
+// Default retry policy
+const (
+	DefaultRetryAttempts   = 4
+	DefaultRetryInterval   = 200 * time.Millisecond
+	DefaultRetryMaxBackoff = 20 * time.Second
+)
+
+// RetryPolicy configures retries of failed requests
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first one.
+	MaxAttempts int
+
+	// Interval before the first retry, it is doubled after each attempt
+	// until MaxInterval is reached. The interval is randomized with jitter.
+	InitialInterval time.Duration
+
+	// Upper bound of the interval between attempts, it also caps the delay
+	// advertised by the server with Retry-After header.
+	MaxInterval time.Duration
+
+	// HTTP status codes to retry, defaults to 429, 502, 503 and 504. The codes
+	// 429 and 503 are retried for any request, other codes and transport
+	// errors are retried only if the request is idempotent.
+	StatusCodes []int
+
+	// Retry non-idempotent requests (e.g. POST) on any retryable failure.
+	NonIdempotent bool
+}
+
+// WithRetry wraps the stack with retries of failed requests. The request is
+// re-sent as is, its body is buffered if it cannot be rewound. Use it with
+// any client of the library:
+//
+//	stack := optimum.WithRetry(http.New(), optimum.RetryPolicy{})
+//	api := surface.New(stack, host)
+//
+// The retry is applied to the protocol stack created by http.New, other
+// implementations of http.Stack are returned unchanged.
+func WithRetry(stack http.Stack, policy RetryPolicy) http.Stack {
+	protocol, ok := stack.(*http.Protocol)
+	if !ok {
+		return stack
+	}
+
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryAttempts
+	}
+
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = DefaultRetryInterval
+	}
+
+	if policy.MaxInterval < policy.InitialInterval {
+		policy.MaxInterval = max(DefaultRetryMaxBackoff, policy.InitialInterval)
+	}
+
+	if len(policy.StatusCodes) == 0 {
+		policy.StatusCodes = []int{
+			nethttp.StatusTooManyRequests,
+			nethttp.StatusBadGateway,
+			nethttp.StatusServiceUnavailable,
+			nethttp.StatusGatewayTimeout,
+		}
+	}
+
+	wrapped := *protocol
+	wrapped.Socket = &retry{Socket: protocol.Socket, policy: policy}
+
+	return &wrapped
+}
+
+type retry struct {
+	http.Socket
+	policy RetryPolicy
+}
+
+func (r *retry) Do(req *nethttp.Request) (*nethttp.Response, error) {
+	if req.Body != nil && req.Body != nethttp.NoBody && req.GetBody == nil {
+		payload, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(payload)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+
+	ctx := req.Context()
+	interval := r.policy.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		rsp, err := r.Socket.Do(req)
+		if attempt >= r.policy.MaxAttempts || !r.retryable(req, rsp, err) {
+			return rsp, err
+		}
+
+		delay := jitter(interval)
+		if rsp != nil {
+			if after := retryAfter(rsp.Header.Get("Retry-After")); after > 0 {
+				delay = min(after, r.policy.MaxInterval)
+			}
+			io.Copy(io.Discard, rsp.Body)
+			rsp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		interval = min(2*interval, r.policy.MaxInterval)
+	}
+}
+
+func (r *retry) retryable(req *nethttp.Request, rsp *nethttp.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	idempotent := r.policy.NonIdempotent || isIdempotent(req)
+
+	if err != nil {
+		var ne net.Error
+		if errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return idempotent
+		}
+		return false
+	}
+
+	if !slices.Contains(r.policy.StatusCodes, rsp.StatusCode) {
+		return false
+	}
+
+	// the request is not processed by the server
+	return idempotent ||
+		rsp.StatusCode == nethttp.StatusTooManyRequests ||
+		rsp.StatusCode == nethttp.StatusServiceUnavailable
+}
+
+type idempotent struct{}
+
+// Idempotent marks the context of requests that are safe to retry on any
+// failure, e.g. writes of objects identified by unique key.
+func Idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotent{}, true)
+}
+
+func isIdempotent(req *nethttp.Request) bool {
+	switch req.Method {
+	case nethttp.MethodGet, nethttp.MethodHead, nethttp.MethodPut, nethttp.MethodDelete, nethttp.MethodOptions:
+		return true
+	}
+
+	marked, _ := req.Context().Value(idempotent{}).(bool)
+	return marked
+}
+
+// parses Retry-After header, either delay in seconds or HTTP date
+func retryAfter(after string) time.Duration {
+	if after == "" {
+		return 0
+	}
+
+	if sec, err := strconv.Atoi(after); err == nil {
+		return time.Duration(sec) * time.Second
+	}
+
+	if at, err := time.Parse(time.RFC1123, after); err == nil {
+		return max(time.Until(at), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,202 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package optimum
+
+import (
+	"context"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fogfish/gurl/v2/http"
+)
+
+// server responds with status codes of the sequence, the last one is repeated
+type server struct {
+	sync.Mutex
+	status []int
+	header nethttp.Header
+	bodies []string
+}
+
+func (s *server) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	b, _ := io.ReadAll(r.Body)
+	s.bodies = append(s.bodies, string(b))
+
+	code := s.status[min(len(s.bodies), len(s.status))-1]
+	for k, v := range s.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(code)
+}
+
+func (s *server) attempts() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.bodies)
+}
+
+func socketOf(policy RetryPolicy) http.Socket {
+	return WithRetry(http.New(), policy).(*http.Protocol).Socket
+}
+
+// the body is not rewindable by net/http
+func bodyOf(s string) io.Reader {
+	return io.MultiReader(strings.NewReader(s))
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	srv := &server{status: []int{503, 200}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	req, err := nethttp.NewRequest(nethttp.MethodPost, ts.URL, bodyOf("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp, err := socketOf(RetryPolicy{InitialInterval: time.Millisecond}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode != 200 {
+		t.Errorf("unexpected status %d", rsp.StatusCode)
+	}
+
+	srv.Lock()
+	defer srv.Unlock()
+	if len(srv.bodies) != 2 || srv.bodies[0] != "payload" || srv.bodies[1] != "payload" {
+		t.Errorf("body is not replayed %q", srv.bodies)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	at := time.Now().Add(5 * time.Second).UTC().Format(nethttp.TimeFormat)
+
+	for _, tt := range []struct {
+		after    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"0", 0, 0},
+		{at, 3 * time.Second, 5 * time.Second},
+		{"Mon, 02 Jan 2006 15:04:05 GMT", 0, 0},
+		{"soon", 0, 0},
+	} {
+		if d := retryAfter(tt.after); d < tt.min || d > tt.max {
+			t.Errorf("retry after %q is %s, expected [%s, %s]", tt.after, d, tt.min, tt.max)
+		}
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	at := time.Now().Add(5 * time.Second).UTC().Format(nethttp.TimeFormat)
+
+	for _, after := range []string{"1", at} {
+		srv := &server{status: []int{429, 200}, header: nethttp.Header{"Retry-After": {after}}}
+		ts := httptest.NewServer(srv)
+
+		req, _ := nethttp.NewRequest(nethttp.MethodGet, ts.URL, nil)
+		socket := socketOf(RetryPolicy{InitialInterval: time.Millisecond, MaxInterval: 50 * time.Millisecond})
+
+		t0 := time.Now()
+		rsp, err := socket.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsp.Body.Close()
+		ts.Close()
+
+		if rsp.StatusCode != 200 || srv.attempts() != 2 {
+			t.Errorf("retry after %q: unexpected status %d after %d attempts", after, rsp.StatusCode, srv.attempts())
+		}
+
+		if d := time.Since(t0); d < 50*time.Millisecond || d > time.Second {
+			t.Errorf("retry after %q: delay %s is not capped by max interval", after, d)
+		}
+	}
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	for _, tt := range []struct {
+		method   string
+		ctx      context.Context
+		policy   RetryPolicy
+		status   int
+		attempts int
+	}{
+		{nethttp.MethodGet, context.Background(), RetryPolicy{}, 502, 3},
+		{nethttp.MethodDelete, context.Background(), RetryPolicy{}, 504, 3},
+		{nethttp.MethodGet, context.Background(), RetryPolicy{}, 500, 1},
+		{nethttp.MethodGet, context.Background(), RetryPolicy{}, 400, 1},
+		{nethttp.MethodPost, context.Background(), RetryPolicy{}, 502, 1},
+		{nethttp.MethodPost, context.Background(), RetryPolicy{}, 504, 1},
+		{nethttp.MethodPost, context.Background(), RetryPolicy{}, 503, 3},
+		{nethttp.MethodPost, context.Background(), RetryPolicy{}, 429, 3},
+		{nethttp.MethodPost, Idempotent(context.Background()), RetryPolicy{}, 502, 3},
+		{nethttp.MethodPost, context.Background(), RetryPolicy{NonIdempotent: true}, 502, 3},
+		{nethttp.MethodPost, context.Background(), RetryPolicy{StatusCodes: []int{500}}, 500, 1},
+		{nethttp.MethodGet, context.Background(), RetryPolicy{StatusCodes: []int{500}}, 500, 3},
+	} {
+		srv := &server{status: []int{tt.status}}
+		ts := httptest.NewServer(srv)
+
+		tt.policy.MaxAttempts = 3
+		tt.policy.InitialInterval = time.Millisecond
+
+		req, _ := nethttp.NewRequestWithContext(tt.ctx, tt.method, ts.URL, bodyOf("payload"))
+		rsp, err := socketOf(tt.policy).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsp.Body.Close()
+		ts.Close()
+
+		if rsp.StatusCode != tt.status || srv.attempts() != tt.attempts {
+			t.Errorf("%s %d: %d attempts, expected %d", tt.method, tt.status, srv.attempts(), tt.attempts)
+		}
+	}
+}
+
+func TestRetryCancelDuringBackoff(t *testing.T) {
+	srv := &server{status: []int{503}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	req, _ := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, ts.URL, nil)
+	socket := socketOf(RetryPolicy{InitialInterval: 10 * time.Second})
+
+	t0 := time.Now()
+	_, err := socket.Do(req)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if d := time.Since(t0); d > time.Second {
+		t.Errorf("backoff is not cancelled, it took %s", d)
+	}
+
+	if srv.attempts() != 1 {
+		t.Errorf("unexpected %d attempts", srv.attempts())
+	}
+}
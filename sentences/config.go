@@ -0,0 +1,90 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package sentences
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fogfish/curie"
+	"github.com/kshard/optimum"
+	"github.com/kshard/optimum/surface"
+)
+
+// Config of `text` data structure. Zero value of any parameter causes usage
+// of the server side default.
+type Config struct {
+	// Embeddings model used to transform text into vectors.
+	Embeddings *Embeddings `json:"embeddings,omitempty"`
+
+	// If defined, it enables Hierarchical Navigable Small World (HNSW)
+	// algorithm for approximate nearest neighbor search.
+	HNSW *surface.Config `json:"hnsw,omitempty"`
+}
+
+// Embeddings model config
+type Embeddings struct {
+	// Model id used to calculate embeddings vector.
+	Model string `json:"model,omitempty"`
+
+	// Size of the output embeddings vector, one of {256, 512, 1024}.
+	Dimension int `json:"dimension,omitempty"`
+}
+
+// Default config of `text` data structure
+func DefaultConfig() Config {
+	return Config{
+		Embeddings: &Embeddings{
+			Model:     "amazon.titan-embed-text-v2:0",
+			Dimension: 256,
+		},
+	}
+}
+
+// Validate config, it reports every violated constraint as optimum.ConfigError
+func (c Config) Validate() error {
+	err := &optimum.ConfigError{Kind: "text"}
+
+	if c.Embeddings != nil {
+		d := c.Embeddings.Dimension
+		err.Check(d == 0 || d == 256 || d == 512 || d == 1024,
+			"embeddings.dimension = %d is not one of {256, 512, 1024}", d)
+	}
+
+	if c.HNSW != nil {
+		var e *optimum.ConfigError
+		if errors.As(c.HNSW.Validate(), &e) {
+			for _, v := range e.Violations {
+				err.Violations = append(err.Violations, "hnsw."+v)
+			}
+		}
+	}
+
+	return err.Err()
+}
+
+// ConfigOf decodes config of `text` data structure instance
+func ConfigOf(inst optimum.Instance) (Config, error) {
+	var c Config
+	if err := inst.DecodeOpts(&c); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// Create new instance of `text` data structure, the config is validated
+// before the request.
+func Create(ctx context.Context, api *optimum.Client, cask curie.IRI, c Config) (*optimum.Created, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return api.Create(ctx, cask, c)
+}
@@ -0,0 +1,389 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package sentences
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Field of sentence metadata used by filters
+type Field string
+
+const (
+	FieldIsPartOf Field = "isPartOf"
+	FieldKeywords Field = "keywords"
+	FieldHeadline Field = "headline"
+)
+
+// Operators of filter expression
+const (
+	OpEq     = "eq"
+	OpIn     = "in"
+	OpPrefix = "prefix"
+	OpAnd    = "and"
+	OpOr     = "or"
+	OpNot    = "not"
+)
+
+// Filter is boolean expression over metadata of sentences, it restricts
+// the retrieval to matching sentences. Use builders (Eq, In, Prefix, And,
+// Or, Not) to construct the expression.
+//
+// List fields (keywords, headline) match if any of elements matches.
+type Filter struct {
+	Op     string   `json:"op"`
+	Field  Field    `json:"field,omitempty"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
+	Args   []Filter `json:"args,omitempty"`
+}
+
+// Eq matches the field equal to the value
+func Eq(field Field, value string) Filter {
+	return Filter{Op: OpEq, Field: field, Value: value}
+}
+
+// In matches the field equal to any of values
+func In(field Field, values ...string) Filter {
+	return Filter{Op: OpIn, Field: field, Values: values}
+}
+
+// Prefix matches isPartOf starting with the value, e.g. documents of the site
+func Prefix(field Field, value string) Filter {
+	return Filter{Op: OpPrefix, Field: field, Value: value}
+}
+
+// And matches if all of expressions match
+func And(args ...Filter) Filter { return Filter{Op: OpAnd, Args: args} }
+
+// Or matches if any of expressions matches
+func Or(args ...Filter) Filter { return Filter{Op: OpOr, Args: args} }
+
+// Not matches if the expression does not match
+func Not(arg Filter) Filter { return Filter{Op: OpNot, Args: []Filter{arg}} }
+
+// Validate the filter expression
+func (f Filter) Validate() error {
+	switch f.Op {
+	case OpEq, OpIn, OpPrefix:
+		switch f.Field {
+		case FieldIsPartOf, FieldKeywords, FieldHeadline:
+		default:
+			return fmt.Errorf("invalid filter: field %q is not one of {%s, %s, %s}", f.Field, FieldIsPartOf, FieldKeywords, FieldHeadline)
+		}
+
+		if f.Op == OpPrefix && f.Field != FieldIsPartOf {
+			return fmt.Errorf("invalid filter: prefix is only supported by %s", FieldIsPartOf)
+		}
+
+		if f.Op == OpIn && len(f.Values) == 0 {
+			return fmt.Errorf("invalid filter: %s in requires values", f.Field)
+		}
+
+		if len(f.Args) != 0 {
+			return fmt.Errorf("invalid filter: %s does not take expressions", f.Op)
+		}
+	case OpAnd, OpOr:
+		if len(f.Args) == 0 {
+			return fmt.Errorf("invalid filter: %s requires expressions", f.Op)
+		}
+	case OpNot:
+		if len(f.Args) != 1 {
+			return fmt.Errorf("invalid filter: not requires one expression")
+		}
+	default:
+		return fmt.Errorf("invalid filter: operator %q is not supported", f.Op)
+	}
+
+	for _, arg := range f.Args {
+		if err := arg.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// String renders the filter as expression, it is parsable by ParseFilter
+func (f Filter) String() string {
+	switch f.Op {
+	case OpEq:
+		return fmt.Sprintf("%s = %q", f.Field, f.Value)
+	case OpPrefix:
+		return fmt.Sprintf("%s ^= %q", f.Field, f.Value)
+	case OpIn:
+		seq := make([]string, len(f.Values))
+		for i, v := range f.Values {
+			seq[i] = strconv.Quote(v)
+		}
+		return fmt.Sprintf("%s in (%s)", f.Field, strings.Join(seq, ", "))
+	case OpAnd, OpOr:
+		seq := make([]string, len(f.Args))
+		for i, arg := range f.Args {
+			seq[i] = arg.String()
+		}
+		return "(" + strings.Join(seq, " "+f.Op+" ") + ")"
+	case OpNot:
+		if len(f.Args) == 1 {
+			return "not " + f.Args[0].String()
+		}
+	}
+
+	return fmt.Sprintf("<invalid %s>", f.Op)
+}
+
+//------------------------------------------------------------------------------
+
+// ParseFilter parses the filter expression:
+//
+//	expr   := term { "or" term }
+//	term   := factor { "and" factor }
+//	factor := "not" factor | "(" expr ")" | field "=" value
+//	        | field "in" "(" value { "," value } ")" | field "^=" value
+//
+// Values are either quoted strings or bare words, e.g.
+//
+//	isPartOf ^= "https://example.com/" and not keywords in (draft, internal)
+func ParseFilter(expr string) (Filter, error) {
+	p := &parser{input: expr}
+	if err := p.next(); err != nil {
+		return Filter{}, err
+	}
+
+	f, err := p.expr()
+	if err != nil {
+		return Filter{}, err
+	}
+
+	if p.tok != "" {
+		return Filter{}, p.errorf("unexpected %q", p.tok)
+	}
+
+	return f, f.Validate()
+}
+
+type parser struct {
+	input  string
+	pos    int
+	at     int
+	tok    string
+	quoted bool
+}
+
+// the position of error is the character of input
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid filter at %d: %s", utf8.RuneCountInString(p.input[:p.at])+1, fmt.Sprintf(format, args...))
+}
+
+// the character at the position and its width in bytes
+func (p *parser) peek() (rune, int) {
+	return utf8.DecodeRuneInString(p.input[p.pos:])
+}
+
+// reads next token
+func (p *parser) next() error {
+	for p.pos < len(p.input) {
+		r, n := p.peek()
+		if !unicode.IsSpace(r) {
+			break
+		}
+		p.pos += n
+	}
+
+	p.at, p.quoted = p.pos, false
+	if p.pos == len(p.input) {
+		p.tok = ""
+		return nil
+	}
+
+	switch c := p.input[p.pos]; {
+	case c == '(' || c == ')' || c == ',' || c == '=':
+		p.pos++
+	case c == '^' && strings.HasPrefix(p.input[p.pos:], "^="):
+		p.pos += 2
+	case c == '"':
+		end := p.pos + 1
+		for end < len(p.input) && p.input[end] != '"' {
+			if p.input[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.input) {
+			return p.errorf("unterminated string")
+		}
+
+		s, err := strconv.Unquote(p.input[p.pos : end+1])
+		if err != nil {
+			return p.errorf("invalid string %s", p.input[p.pos:end+1])
+		}
+		p.pos = end + 1
+		p.tok, p.quoted = s, true
+		return nil
+	default:
+		for p.pos < len(p.input) {
+			r, n := p.peek()
+			if unicode.IsSpace(r) || strings.ContainsRune("()=,\"^", r) {
+				break
+			}
+			p.pos += n
+		}
+		if p.pos == p.at {
+			_, n := p.peek()
+			p.pos += n
+		}
+	}
+
+	p.tok = p.input[p.at:p.pos]
+	return nil
+}
+
+func (p *parser) keyword(kw string) bool {
+	return !p.quoted && p.tok == kw
+}
+
+func (p *parser) expr() (Filter, error) {
+	return p.binary(OpOr, p.term)
+}
+
+func (p *parser) term() (Filter, error) {
+	return p.binary(OpAnd, p.factor)
+}
+
+func (p *parser) binary(op string, operand func() (Filter, error)) (Filter, error) {
+	f, err := operand()
+	if err != nil {
+		return Filter{}, err
+	}
+
+	args := []Filter{f}
+	for p.keyword(op) {
+		if err := p.next(); err != nil {
+			return Filter{}, err
+		}
+
+		f, err := operand()
+		if err != nil {
+			return Filter{}, err
+		}
+		args = append(args, f)
+	}
+
+	if len(args) == 1 {
+		return args[0], nil
+	}
+
+	return Filter{Op: op, Args: args}, nil
+}
+
+func (p *parser) factor() (Filter, error) {
+	switch {
+	case p.keyword(OpNot):
+		if err := p.next(); err != nil {
+			return Filter{}, err
+		}
+
+		f, err := p.factor()
+		if err != nil {
+			return Filter{}, err
+		}
+		return Not(f), nil
+
+	case p.keyword("("):
+		if err := p.next(); err != nil {
+			return Filter{}, err
+		}
+
+		f, err := p.expr()
+		if err != nil {
+			return Filter{}, err
+		}
+
+		if !p.keyword(")") {
+			return Filter{}, p.errorf("expected ) but found %q", p.tok)
+		}
+		return f, p.next()
+
+	default:
+		return p.condition()
+	}
+}
+
+func (p *parser) condition() (Filter, error) {
+	if p.tok == "" || p.quoted {
+		return Filter{}, p.errorf("expected field but found %q", p.tok)
+	}
+	field := Field(p.tok)
+
+	if err := p.next(); err != nil {
+		return Filter{}, err
+	}
+
+	switch {
+	case p.keyword("="):
+		value, err := p.value()
+		if err != nil {
+			return Filter{}, err
+		}
+		return Eq(field, value), nil
+
+	case p.keyword("^="):
+		value, err := p.value()
+		if err != nil {
+			return Filter{}, err
+		}
+		return Prefix(field, value), nil
+
+	case p.keyword(OpIn):
+		if err := p.next(); err != nil {
+			return Filter{}, err
+		}
+		if !p.keyword("(") {
+			return Filter{}, p.errorf("expected ( but found %q", p.tok)
+		}
+
+		values := []string{}
+		for {
+			value, err := p.value()
+			if err != nil {
+				return Filter{}, err
+			}
+			values = append(values, value)
+
+			if p.keyword(")") {
+				return In(field, values...), p.next()
+			}
+
+			if !p.keyword(",") {
+				return Filter{}, p.errorf("expected , or ) but found %q", p.tok)
+			}
+		}
+
+	default:
+		return Filter{}, p.errorf("expected =, ^= or in after %s but found %q", field, p.tok)
+	}
+}
+
+// reads the value following the current token
+func (p *parser) value() (string, error) {
+	if err := p.next(); err != nil {
+		return "", err
+	}
+
+	if p.tok == "" || (!p.quoted && strings.Contains("()=,^=", p.tok)) {
+		return "", p.errorf("expected value but found %q", p.tok)
+	}
+
+	value := p.tok
+	return value, p.next()
+}
@@ -0,0 +1,180 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package sentences
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, tt := range []struct {
+		expr   string
+		filter Filter
+	}{
+		// precedence
+		{
+			`keywords = a or headline = b and isPartOf = c`,
+			Or(Eq(FieldKeywords, "a"), And(Eq(FieldHeadline, "b"), Eq(FieldIsPartOf, "c"))),
+		},
+		{
+			`(keywords = a or headline = b) and isPartOf = c`,
+			And(Or(Eq(FieldKeywords, "a"), Eq(FieldHeadline, "b")), Eq(FieldIsPartOf, "c")),
+		},
+		{
+			`not keywords = a and headline = b`,
+			And(Not(Eq(FieldKeywords, "a")), Eq(FieldHeadline, "b")),
+		},
+		{
+			`not (keywords = a or headline = b)`,
+			Not(Or(Eq(FieldKeywords, "a"), Eq(FieldHeadline, "b"))),
+		},
+		{
+			`not not keywords = a`,
+			Not(Not(Eq(FieldKeywords, "a"))),
+		},
+		{
+			`keywords = a and headline = b and isPartOf = c`,
+			And(Eq(FieldKeywords, "a"), Eq(FieldHeadline, "b"), Eq(FieldIsPartOf, "c")),
+		},
+		// quoting
+		{
+			`keywords = "new york"`,
+			Eq(FieldKeywords, "new york"),
+		},
+		{
+			`keywords = "and" or headline = "not"`,
+			Or(Eq(FieldKeywords, "and"), Eq(FieldHeadline, "not")),
+		},
+		{
+			`keywords = "a \"b\" (c), d = e"`,
+			Eq(FieldKeywords, `a "b" (c), d = e`),
+		},
+		{
+			`keywords in (a, "b c", "d,e")`,
+			In(FieldKeywords, "a", "b c", "d,e"),
+		},
+		{
+			`isPartOf ^= "https://example.com/" and isPartOf=https://example.com/a`,
+			And(Prefix(FieldIsPartOf, "https://example.com/"), Eq(FieldIsPartOf, "https://example.com/a")),
+		},
+		// non-ascii
+		{
+			`keywords = à`,
+			Eq(FieldKeywords, "à"),
+		},
+		{
+			`keywords in (München, 東京, "naïve café")`,
+			In(FieldKeywords, "München", "東京", "naïve café"),
+		},
+		{
+			"keywords = à or\tkeywords = ą",
+			Or(Eq(FieldKeywords, "à"), Eq(FieldKeywords, "ą")),
+		},
+	} {
+		f, err := ParseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.expr, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(f, tt.filter) {
+			t.Errorf("%s: unexpected filter\n%+v\nexpected\n%+v", tt.expr, f, tt.filter)
+		}
+	}
+}
+
+func TestParseFilterError(t *testing.T) {
+	for _, tt := range []struct {
+		expr string
+		err  string
+	}{
+		{``, `at 1: expected field`},
+		{`keywords`, `at 9: expected =, ^= or in`},
+		{`keywords = `, `at 12: expected value`},
+		{`keywords = )`, `at 12: expected value`},
+		{`keywords = "abc`, `at 12: unterminated string`},
+		{`keywords in a`, `at 13: expected (`},
+		{`keywords in (a, b`, `at 18: expected , or )`},
+		{`(keywords = a`, `at 14: expected )`},
+		{`keywords = a b`, `at 14: unexpected "b"`},
+		{`"keywords" = a`, `at 1: expected field`},
+		{`keywords = à or`, `at 16: expected field`},
+		{`keywords = "東京" and (`, `at 22: expected field`},
+		{`keywords = a ^ b`, `at 14: unexpected "^"`},
+		{`keywords ^= a`, `prefix is only supported by isPartOf`},
+		{`title = a`, `field "title" is not one of`},
+	} {
+		_, err := ParseFilter(tt.expr)
+		if err == nil {
+			t.Errorf("%s: invalid filter is accepted", tt.expr)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: unexpected error %q, expected %q", tt.expr, err, tt.err)
+		}
+	}
+}
+
+func TestFilterString(t *testing.T) {
+	for _, f := range []Filter{
+		Eq(FieldKeywords, "a"),
+		Eq(FieldKeywords, "and"),
+		Eq(FieldHeadline, `a "b" (c), d = e \ f`),
+		Eq(FieldHeadline, "naïve café\t東京"),
+		In(FieldKeywords, "a", "b c", "d,e"),
+		Prefix(FieldIsPartOf, "https://example.com/"),
+		Not(Not(Eq(FieldKeywords, "a"))),
+		And(Or(Eq(FieldKeywords, "a"), Eq(FieldHeadline, "b")), Not(In(FieldIsPartOf, "c"))),
+		Or(And(Eq(FieldKeywords, "a"), Eq(FieldHeadline, "b")), And(Eq(FieldKeywords, "c"), Eq(FieldHeadline, "d"))),
+		And(And(Eq(FieldKeywords, "a"), Eq(FieldHeadline, "b")), Eq(FieldKeywords, "c")),
+	} {
+		expr := f.String()
+
+		parsed, err := ParseFilter(expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", expr, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(parsed, f) {
+			t.Errorf("%s: filter is not preserved\n%+v\nexpected\n%+v", expr, parsed, f)
+		}
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	for _, tt := range []struct {
+		filter Filter
+		err    string
+	}{
+		{Eq("title", "a"), `field "title" is not one of`},
+		{Prefix(FieldKeywords, "a"), `prefix is only supported by isPartOf`},
+		{In(FieldKeywords), `keywords in requires values`},
+		{Filter{Op: OpEq, Field: FieldKeywords, Args: []Filter{Eq(FieldKeywords, "a")}}, `eq does not take expressions`},
+		{And(), `and requires expressions`},
+		{Or(), `or requires expressions`},
+		{Filter{Op: OpNot}, `not requires one expression`},
+		{Filter{Op: "xor"}, `operator "xor" is not supported`},
+		{And(Eq(FieldKeywords, "a"), Or(Eq("title", "b"))), `field "title" is not one of`},
+		{Not(Prefix(FieldHeadline, "a")), `prefix is only supported by isPartOf`},
+	} {
+		err := tt.filter.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%v: unexpected error %v, expected %q", tt.filter, err, tt.err)
+		}
+	}
+
+	valid := And(Eq(FieldKeywords, "a"), Not(Or(In(FieldHeadline, "b"), Prefix(FieldIsPartOf, "c"))))
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
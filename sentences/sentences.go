@@ -10,26 +10,51 @@ package sentences
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 
 	"github.com/fogfish/curie"
 	"github.com/fogfish/gurl/v2/http"
-	ƒ "github.com/fogfish/gurl/v2/http/recv"
 	ø "github.com/fogfish/gurl/v2/http/send"
+	"github.com/kshard/optimum"
+	"github.com/kshard/optimum/internal/batch"
 )
 
 // Client for reading/writing natural language text and searching for nearest neighbor.
 type Client struct {
 	http.Stack
 
-	host ø.Authority
+	host        ø.Authority
+	concurrency int
+
+	// server does not support batch queries
+	nobatch atomic.Bool
+}
+
+// ClientOption of the client
+type ClientOption func(*Client)
+
+// WithConcurrency sets the number of concurrent queries, which are used by
+// QueryBatch if server does not support batches. The default is 8.
+func WithConcurrency(n int) ClientOption {
+	return func(api *Client) {
+		api.concurrency = n
+	}
 }
 
 // Creates the client for reading/writing natural language text and searching for nearest neighbor.
-func New(stack http.Stack, host string) *Client {
-	return &Client{
-		Stack: stack,
-		host:  ø.Authority(host),
+func New(stack http.Stack, host string, opts ...ClientOption) *Client {
+	api := &Client{
+		Stack:       stack,
+		host:        ø.Authority(host),
+		concurrency: 8,
 	}
+
+	for _, opt := range opts {
+		opt(api)
+	}
+
+	return api
 }
 
 // Write the sentence
@@ -49,13 +74,85 @@ func (api *Client) Write(ctx context.Context, cask curie.IRI, bag []Sentence) er
 				V: bag,
 			}),
 
-			ƒ.Status.Accepted,
+			optimum.Code(http.StatusAccepted),
 		),
 	)
 }
 
-// Query nearest neighbor text to the given sample.
+// Get the sentence by identity, it returns error that matches
+// optimum.ErrNotFound if the sentence does not exist.
+func (api *Client) Get(ctx context.Context, cask curie.IRI, id string) (*Sentence, error) {
+	return http.IO[Sentence](
+		api.WithContext(ctx),
+		http.GET(
+			ø.URI("%s/ds/%s/%s/object/%s", api.host, curie.Prefix(cask), curie.Reference(cask), id),
+			ø.Accept.JSON,
+
+			optimum.Code(http.StatusOK),
+		),
+	)
+}
+
+// MultiGet sentences by identity, missing sentences are omitted from
+// the result.
+func (api *Client) MultiGet(ctx context.Context, cask curie.IRI, ids []string) ([]Sentence, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	bag, err := http.IO[struct {
+		V []Sentence `json:"object"`
+	}](
+		api.WithContext(ctx),
+		http.GET(
+			ø.URI("%s/ds/%s/%s/object", api.host, curie.Prefix(cask), curie.Reference(cask)),
+			ø.Accept.JSON,
+			ø.ContentType.JSON,
+			ø.Send(struct {
+				K []string `json:"keys"`
+			}{
+				K: ids,
+			}),
+
+			optimum.Code(http.StatusOK),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return bag.V, nil
+}
+
+// Delete the sentence(s) by identity
+func (api *Client) Delete(ctx context.Context, cask curie.IRI, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return api.IO(ctx,
+		http.DELETE(
+			ø.URI("%s/ds/%s/%s/object", api.host, curie.Prefix(cask), curie.Reference(cask)),
+			ø.Accept.JSON,
+			ø.ContentType.JSON,
+			ø.Send(struct {
+				K []string `json:"keys"`
+			}{
+				K: ids,
+			}),
+
+			optimum.Code(http.StatusOK, http.StatusAccepted, http.StatusNoContent),
+		),
+	)
+}
+
+// Query nearest neighbor text to the given sample. The filter of query is
+// validated before sending.
 func (api *Client) Query(ctx context.Context, cask curie.IRI, q Query) (*Result, error) {
+	if err := validate(q); err != nil {
+		return nil, err
+	}
+
 	return http.IO[Result](
 		api.WithContext(ctx),
 		http.GET(
@@ -68,7 +165,88 @@ func (api *Client) Query(ctx context.Context, cask curie.IRI, q Query) (*Result,
 				Q: q,
 			}),
 
-			ƒ.Status.OK,
+			optimum.Code(http.StatusOK),
+		),
+	)
+}
+
+// QueryBatch evaluates queries within one request, results are aligned with
+// queries. The client falls back to concurrent queries (see WithConcurrency)
+// if server does not support batches.
+func (api *Client) QueryBatch(ctx context.Context, cask curie.IRI, seq []Query) ([]Result, error) {
+	if len(seq) == 0 {
+		return nil, nil
+	}
+
+	for _, q := range seq {
+		if err := validate(q); err != nil {
+			return nil, err
+		}
+	}
+
+	ambiguous := false
+	if !api.nobatch.Load() {
+		rs, err := api.queryBatch(ctx, cask, seq)
+		switch {
+		case batch.Unsupported(err):
+			api.nobatch.Store(true)
+		case batch.Ambiguous(err):
+			ambiguous = true
+		default:
+			return rs, err
+		}
+	}
+
+	rs, err := batch.Map(ctx, api.concurrency, seq,
+		func(ctx context.Context, q Query) (Result, error) {
+			rs, err := api.Query(ctx, cask, q)
+			if err != nil {
+				return Result{}, err
+			}
+			return *rs, nil
+		},
+	)
+	if ambiguous && err == nil {
+		api.nobatch.Store(true)
+	}
+
+	return rs, err
+}
+
+func (api *Client) queryBatch(ctx context.Context, cask curie.IRI, seq []Query) ([]Result, error) {
+	rs, err := http.IO[struct {
+		Results []Result `json:"results"`
+	}](
+		api.WithContext(ctx),
+		http.GET(
+			ø.URI("%s/ds/%s/%s/queries", api.host, curie.Prefix(cask), curie.Reference(cask)),
+			ø.Accept.JSON,
+			ø.ContentType.JSON,
+			ø.Send(struct {
+				Q []Query `json:"queries"`
+			}{
+				Q: seq,
+			}),
+
+			optimum.Code(http.StatusOK),
 		),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rs.Results) != len(seq) {
+		return nil, fmt.Errorf("batch of %d queries returns %d results", len(seq), len(rs.Results))
+	}
+
+	return rs.Results, nil
+}
+
+// query is validated before sending
+func validate(q Query) error {
+	if q.Filter != nil {
+		return q.Filter.Validate()
+	}
+
+	return nil
 }
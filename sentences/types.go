@@ -17,6 +17,10 @@ import (
 
 // Sentence defines textual content
 type Sentence struct {
+	// Unique identity of the text block, it is assigned by the server if not
+	// defined. The identity is required to delete the text block.
+	ID string `json:"id,omitempty"`
+
 	// Short text block.
 	Text schemaorg.Text `json:"text,omitempty"`
 
@@ -55,6 +59,7 @@ type Query struct {
 	EfSearch int     `json:"efSearch,omitempty"`
 	Distance float32 `json:"distance,omitempty"`
 	Text     string  `json:"text"`
+	Filter   *Filter `json:"filter,omitempty"`
 }
 
 // Results from the query
@@ -10,16 +10,24 @@ package sentences
 
 import (
 	"bytes"
-	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/fogfish/curie"
 	"github.com/fogfish/gurl/v2/http"
-	ƒ "github.com/fogfish/gurl/v2/http/recv"
 	ø "github.com/fogfish/gurl/v2/http/send"
+	"github.com/kshard/optimum"
+	"github.com/kshard/optimum/internal/chunks"
 )
 
+// Media type of JSON lines
+const MediaTypeJSONLines = "application/x-ndjson"
+
 // Client for streaming to Graph-based Nearest Neighbor Search Algorithms
 type Writer struct {
 	http.Stack
@@ -29,18 +37,80 @@ type Writer struct {
 
 	chunk int
 	buf   bytes.Buffer
-	zip   *gzip.Writer
 	seq   *json.Encoder
+
+	// offset of input written to buffer
+	offset int64
+
+	parallel    int
+	pipe        *chunks.Pipeline
+	compression optimum.Compression
+	level       int
+	envelope    bool
+
+	// format supported by server, it is probed once
+	probe sync.Once
+	wire  atomic.Int32
+}
+
+// format negotiated with server
+const (
+	wireUnknown int32 = iota
+	wireLines
+	wireEnvelope
+)
+
+// Option of the writer
+type Option func(*Writer)
+
+// WithParallel sets the number of chunks uploaded concurrently. The memory
+// used by writer is bounded by n+1 chunks. The chunks are sequentially
+// uploaded by default.
+func WithParallel(n int) Option {
+	return func(stream *Writer) {
+		stream.parallel = n
+	}
+}
+
+// WithCompression sets the compression of chunks. The level is specific to
+// the algorithm, zero value is the default level. Chunks are compressed with
+// gzip by default.
+func WithCompression(c optimum.Compression, level int) Option {
+	return func(stream *Writer) {
+		stream.compression = c
+		stream.level = level
+	}
+}
+
+// WithEnvelope enables compatibility mode, the chunk is gzip-ed and sent
+// within JSON document as base64 encoded field. Otherwise, the chunk is sent
+// as compressed JSON lines with Content-Encoding header if server advertises
+// them (Accept-Post header of OPTIONS response), the writer falls back to
+// envelope if server rejects JSON lines as unsupported media type. If server
+// does not support OPTIONS, JSON lines are tried, any rejection of chunks by
+// the first requests falls back to envelope.
+func WithEnvelope() Option {
+	return func(stream *Writer) {
+		stream.envelope = true
+	}
 }
 
 // Creates the client for streaming to Graph-based Nearest Neighbor Search Algorithms
-func NewWriter(stack http.Stack, host string, cask curie.IRI, chunk int) *Writer {
+func NewWriter(stack http.Stack, host string, cask curie.IRI, chunk int, opts ...Option) *Writer {
 	stream := &Writer{
-		Stack: stack,
-		host:  ø.Authority(host),
-		cask:  cask,
-		chunk: chunk,
+		Stack:       stack,
+		host:        ø.Authority(host),
+		cask:        cask,
+		chunk:       chunk,
+		parallel:    1,
+		compression: optimum.Gzip,
+	}
+
+	for _, opt := range opts {
+		opt(stream)
 	}
+
+	stream.pipe = chunks.New(stream.parallel)
 	stream.reset()
 
 	return stream
@@ -48,35 +118,206 @@ func NewWriter(stack http.Stack, host string, cask curie.IRI, chunk int) *Writer
 
 func (stream *Writer) reset() {
 	stream.buf.Reset()
-	stream.zip = gzip.NewWriter(&stream.buf)
-	stream.seq = json.NewEncoder(stream.zip)
+	stream.seq = json.NewEncoder(&stream.buf)
 }
 
-// Write vector
+// Write sentence, the offset of input is advanced by one.
 func (stream *Writer) Write(ctx context.Context, v Sentence) error {
+	return stream.WriteAt(ctx, v, stream.offset+1)
+}
+
+// WriteAt writes sentence read from the input at given offset. The offset is
+// the position of input right after the sentence (e.g. bytes or lines consumed
+// from the file). Use Offset to find out which part of input is acknowledged
+// by the server.
+func (stream *Writer) WriteAt(ctx context.Context, v Sentence, offset int64) error {
+	if err := stream.pipe.Err(); err != nil {
+		return err
+	}
+
 	if err := stream.seq.Encode(v); err != nil {
 		return err
 	}
+	stream.offset = offset
 
 	if stream.buf.Len() >= stream.chunk {
-		return stream.Sync(ctx)
+		return stream.flush(ctx)
 	}
 
 	return nil
 }
 
-// Sync local cache
-func (stream *Writer) Sync(ctx context.Context) (err error) {
-	defer stream.reset()
+// Delete sentence, the offset of input is advanced by one.
+func (stream *Writer) Delete(ctx context.Context, id string) error {
+	return stream.DeleteAt(ctx, id, stream.offset+1)
+}
+
+// DeleteAt writes tombstone of the sentence read from the input at given
+// offset. The tombstone is batched together with sentences.
+func (stream *Writer) DeleteAt(ctx context.Context, id string, offset int64) error {
+	if err := stream.pipe.Err(); err != nil {
+		return err
+	}
 
-	if err := stream.zip.Close(); err != nil {
+	if err := stream.seq.Encode(tombstone{ID: id, Deleted: true}); err != nil {
 		return err
 	}
+	stream.offset = offset
+
+	if stream.buf.Len() >= stream.chunk {
+		return stream.flush(ctx)
+	}
+
+	return nil
+}
+
+// tombstone of the sentence
+type tombstone struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// Sync local cache, it waits for all chunks in-flight even if the cache is
+// not sent. The error of the earliest failed chunk is preferred.
+func (stream *Writer) Sync(ctx context.Context) error {
+	err := stream.flush(ctx)
+	if failed := stream.pipe.Wait(); failed != nil {
+		return failed
+	}
+
+	return err
+}
+
+// Close the writer, it syncs local cache and waits for all chunks in-flight.
+// The error of the earliest failed chunk is returned.
+func (stream *Writer) Close(ctx context.Context) error {
+	return stream.Sync(ctx)
+}
+
+// Offset of input acknowledged by the server, the input before the offset
+// is safe to skip when the upload is resumed.
+func (stream *Writer) Offset() int64 { return stream.pipe.Offset() }
+
+// sends the chunk of local cache
+func (stream *Writer) flush(ctx context.Context) error {
+	defer stream.reset()
 
 	if stream.buf.Len() == 0 {
 		return nil
 	}
 
+	chunk := bytes.Clone(stream.buf.Bytes())
+
+	return stream.pipe.Send(ctx, stream.offset,
+		func(ctx context.Context) error {
+			if stream.envelope {
+				return stream.sendEnvelope(ctx, chunk)
+			}
+
+			stream.probe.Do(func() {
+				stream.wire.Store(stream.advertised(ctx))
+			})
+
+			if stream.wire.Load() == wireEnvelope {
+				return stream.sendEnvelope(ctx, chunk)
+			}
+
+			err := stream.send(ctx, chunk)
+			if err == nil {
+				stream.wire.CompareAndSwap(wireUnknown, wireLines)
+				return nil
+			}
+
+			// the bad request is the payload error once server accepts JSON lines
+			var e *optimum.Error
+			if errors.As(err, &e) && (e.StatusCode == nethttp.StatusUnsupportedMediaType ||
+				(e.StatusCode == nethttp.StatusBadRequest && stream.wire.Load() == wireUnknown)) {
+				stream.wire.Store(wireEnvelope)
+				return stream.sendEnvelope(ctx, chunk)
+			}
+
+			return err
+		},
+	)
+}
+
+// server advertises JSON lines with Accept-Post header of OPTIONS response.
+// The format is unknown if server does not support OPTIONS, the writer uses
+// envelope if the probe fails otherwise.
+func (stream *Writer) advertised(ctx context.Context) int32 {
+	var accept []string
+
+	err := stream.Stack.IO(optimum.Idempotent(ctx),
+		http.Join(
+			ø.Method(nethttp.MethodOptions),
+			ø.URI("%s/ds/%s/%s/objects", stream.host, curie.Prefix(stream.cask), curie.Reference(stream.cask)),
+
+			optimum.Code(http.StatusOK, http.StatusNoContent),
+			func(cat *http.Context) error {
+				accept = cat.Response.Header.Values("Accept-Post")
+				return nil
+			},
+		),
+	)
+
+	var e *optimum.Error
+	switch {
+	case errors.As(err, &e) && (e.StatusCode == nethttp.StatusNotFound ||
+		e.StatusCode == nethttp.StatusMethodNotAllowed ||
+		e.StatusCode == nethttp.StatusNotImplemented):
+		return wireUnknown
+	case err != nil:
+		return wireEnvelope
+	}
+
+	for _, header := range accept {
+		for _, media := range strings.Split(header, ",") {
+			media, _, _ = strings.Cut(media, ";")
+			if strings.EqualFold(strings.TrimSpace(media), MediaTypeJSONLines) {
+				return wireLines
+			}
+		}
+	}
+
+	return wireEnvelope
+}
+
+// sends chunk as compressed JSON lines
+func (stream *Writer) send(ctx context.Context, chunk []byte) error {
+	pkt, err := compress(stream.compression, stream.level, chunk)
+	if err != nil {
+		return err
+	}
+
+	req := []http.Arrow{
+		ø.URI("%s/ds/%s/%s/objects", stream.host, curie.Prefix(stream.cask), curie.Reference(stream.cask)),
+		ø.Accept.JSON,
+		ø.ContentType.Set(MediaTypeJSONLines),
+	}
+	if stream.compression != optimum.Identity {
+		req = append(req, ø.Header("Content-Encoding", string(stream.compression)))
+	}
+	req = append(req,
+		ø.Send(pkt),
+
+		optimum.Code(http.StatusAccepted),
+	)
+
+	return stream.Stack.IO(ctx, http.POST(req...))
+}
+
+// sends gzip-ed chunk within JSON document
+func (stream *Writer) sendEnvelope(ctx context.Context, chunk []byte) error {
+	level := 0
+	if stream.compression == optimum.Gzip {
+		level = stream.level
+	}
+
+	pkt, err := compress(optimum.Gzip, level, chunk)
+	if err != nil {
+		return err
+	}
+
 	return stream.Stack.IO(ctx,
 		http.POST(
 			ø.URI("%s/ds/%s/%s/objects", stream.host, curie.Prefix(stream.cask), curie.Reference(stream.cask)),
@@ -85,10 +326,29 @@ func (stream *Writer) Sync(ctx context.Context) (err error) {
 			ø.Send(struct {
 				V []byte `json:"object"`
 			}{
-				V: stream.buf.Bytes(),
+				V: pkt,
 			}),
 
-			ƒ.Status.Accepted,
+			optimum.Code(http.StatusAccepted),
 		),
 	)
 }
+
+func compress(c optimum.Compression, level int, chunk []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := c.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(chunk); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
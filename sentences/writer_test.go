@@ -0,0 +1,163 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package sentences
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fogfish/curie"
+	"github.com/fogfish/gurl/v2/http"
+	"github.com/kshard/optimum"
+)
+
+// server accepts envelopes, JSON lines are responded with codes of the
+// sequence, the last one is repeated
+type server struct {
+	sync.Mutex
+	options  int
+	accept   string
+	lines    []int
+	envelope int
+	log      []string
+}
+
+func (s *server) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if r.Method == nethttp.MethodOptions {
+		s.log = append(s.log, "options")
+		if s.accept != "" {
+			w.Header().Set("Accept-Post", s.accept)
+		}
+		w.WriteHeader(s.options)
+		return
+	}
+
+	switch r.Header.Get("Content-Type") {
+	case MediaTypeJSONLines:
+		code := s.lines[min(s.count("lines"), len(s.lines)-1)]
+		s.log = append(s.log, "lines")
+		w.WriteHeader(code)
+	default:
+		s.log = append(s.log, "envelope")
+		w.WriteHeader(s.envelope)
+	}
+}
+
+func (s *server) count(kind string) int {
+	n := 0
+	for _, x := range s.log {
+		if x == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *server) requests() []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string{}, s.log...)
+}
+
+func TestWriterFormat(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		opts   []Option
+		srv    *server
+		failed int
+		log    []string
+	}{
+		{
+			name: "lines are advertised",
+			srv:  &server{options: 200, accept: "application/json, application/x-ndjson", lines: []int{202}, envelope: 202},
+			log:  []string{"options", "lines", "lines"},
+		},
+		{
+			name: "lines are not advertised",
+			srv:  &server{options: 204, accept: "application/json", lines: []int{202}, envelope: 202},
+			log:  []string{"options", "envelope", "envelope"},
+		},
+		{
+			name: "probe fails",
+			srv:  &server{options: 403, lines: []int{202}, envelope: 202},
+			log:  []string{"options", "envelope", "envelope"},
+		},
+		{
+			name: "advertised lines are unsupported",
+			srv:  &server{options: 200, accept: MediaTypeJSONLines, lines: []int{415}, envelope: 202},
+			log:  []string{"options", "lines", "envelope", "envelope"},
+		},
+		{
+			name:   "advertised lines are invalid",
+			srv:    &server{options: 200, accept: MediaTypeJSONLines, lines: []int{400}, envelope: 202},
+			failed: 400,
+			log:    []string{"options", "lines"},
+		},
+		{
+			name: "probe-less lines are rejected",
+			srv:  &server{options: 405, lines: []int{400}, envelope: 202},
+			log:  []string{"options", "lines", "envelope", "envelope"},
+		},
+		{
+			name: "probe-less lines are unsupported",
+			srv:  &server{options: 404, lines: []int{415}, envelope: 202},
+			log:  []string{"options", "lines", "envelope", "envelope"},
+		},
+		{
+			name:   "probe-less lines are accepted",
+			srv:    &server{options: 501, lines: []int{202, 400}, envelope: 202},
+			failed: 400,
+			log:    []string{"options", "lines", "lines"},
+		},
+		{
+			name: "envelope is forced",
+			opts: []Option{WithEnvelope()},
+			srv:  &server{options: 200, accept: MediaTypeJSONLines, lines: []int{202}, envelope: 202},
+			log:  []string{"envelope", "envelope"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.srv)
+			defer ts.Close()
+
+			// every sentence is sent as own chunk
+			stream := NewWriter(http.New(), ts.URL, curie.New("text:example"), 1, tt.opts...)
+
+			var err error
+			for _, id := range []string{"a", "b"} {
+				if err = stream.Write(context.Background(), Sentence{ID: id, Text: "text"}); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = stream.Close(context.Background())
+			}
+
+			var e *optimum.Error
+			switch {
+			case tt.failed == 0 && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case tt.failed != 0 && (!errors.As(err, &e) || e.StatusCode != tt.failed):
+				t.Errorf("unexpected error %v, expected status %d", err, tt.failed)
+			}
+
+			if log := tt.srv.requests(); !slices.Equal(log, tt.log) {
+				t.Errorf("unexpected requests %v, expected %v", log, tt.log)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package surface
+
+import (
+	"context"
+
+	"github.com/fogfish/curie"
+	"github.com/kshard/optimum"
+)
+
+// Vector distance functions
+const (
+	Cosine    = "cosine"
+	Euclidean = "euclidean"
+)
+
+// Config of `hnsw` data structure. Zero value of any parameter causes usage
+// of the server side default.
+type Config struct {
+	// Maximum number of connections per node at intermediate layers, [4, 1024].
+	M int `json:"m,omitempty"`
+
+	// Maximum number of connections per node at the base layer, [4, 1024].
+	M0 int `json:"m0,omitempty"`
+
+	// Number of candidate nodes evaluated during graph construction, [200, 1000].
+	EfConstruction int `json:"efConstruction,omitempty"`
+
+	// Vector distance function, either "cosine" or "euclidean".
+	Surface string `json:"surface,omitempty"`
+
+	// Dimension of vectors, [1, 65536]. The dimension is not checked by
+	// clients if it is not defined.
+	Dimension int `json:"dimension,omitempty"`
+}
+
+// Default config of `hnsw` data structure
+func DefaultConfig() Config {
+	return Config{
+		M:              8,
+		M0:             64,
+		EfConstruction: 200,
+		Surface:        Cosine,
+	}
+}
+
+// Validate config, it reports every violated constraint as optimum.ConfigError
+func (c Config) Validate() error {
+	err := &optimum.ConfigError{Kind: "hnsw"}
+
+	err.Check(c.M == 0 || (c.M >= 4 && c.M <= 1024),
+		"m = %d is not in range [4, 1024]", c.M)
+	err.Check(c.M0 == 0 || (c.M0 >= 4 && c.M0 <= 1024),
+		"m0 = %d is not in range [4, 1024]", c.M0)
+	err.Check(c.EfConstruction == 0 || (c.EfConstruction >= 200 && c.EfConstruction <= 1000),
+		"efConstruction = %d is not in range [200, 1000]", c.EfConstruction)
+	err.Check(c.Surface == "" || c.Surface == Cosine || c.Surface == Euclidean,
+		"surface = %q is not one of {%q, %q}", c.Surface, Cosine, Euclidean)
+	err.Check(c.Dimension >= 0 && c.Dimension <= 65536,
+		"dimension = %d is not in range [1, 65536]", c.Dimension)
+
+	return err.Err()
+}
+
+// ConfigOf decodes config of `hnsw` data structure instance
+func ConfigOf(inst optimum.Instance) (Config, error) {
+	var c Config
+	if err := inst.DecodeOpts(&c); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// DimensionOf returns dimension of vectors in `hnsw` data structure instance,
+// either configured or reported by the server. Zero value means the dimension
+// is unknown.
+func DimensionOf(inst optimum.Instance) (int, error) {
+	c, err := ConfigOf(inst)
+	if err != nil {
+		return 0, err
+	}
+
+	if c.Dimension != 0 {
+		return c.Dimension, nil
+	}
+
+	return inst.Dimension, nil
+}
+
+// Create new instance of `hnsw` data structure, the config is validated
+// before the request.
+func Create(ctx context.Context, api *optimum.Client, cask curie.IRI, c Config) (*optimum.Created, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return api.Create(ctx, cask, c)
+}
@@ -0,0 +1,57 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package surface
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Key is either textual or binary sort key
+type Key interface{ ~string | ~[]uint8 }
+
+// SortKeyPrefix matches sort keys starting with the prefix
+func SortKeyPrefix[T Key](prefix T) *SortKey {
+	return &SortKey{Prefix: []uint8(prefix)}
+}
+
+// SortKeyBetween matches sort keys within the range [from, to]
+func SortKeyBetween[T Key](from, to T) *SortKey {
+	return &SortKey{From: []uint8(from), To: []uint8(to)}
+}
+
+// SortKeyFrom matches sort keys greater or equal to the bound
+func SortKeyFrom[T Key](from T) *SortKey {
+	return &SortKey{From: []uint8(from)}
+}
+
+// SortKeyTo matches sort keys less or equal to the bound
+func SortKeyTo[T Key](to T) *SortKey {
+	return &SortKey{To: []uint8(to)}
+}
+
+// WithPrefix combines the range with the prefix
+func (sk *SortKey) WithPrefix(prefix []uint8) *SortKey {
+	sk.Prefix = prefix
+	return sk
+}
+
+// Validate the predicate
+func (sk *SortKey) Validate() error {
+	if len(sk.Prefix) == 0 && len(sk.From) == 0 && len(sk.To) == 0 {
+		return errors.New("invalid sort key predicate: neither prefix nor range is defined")
+	}
+
+	if len(sk.From) != 0 && len(sk.To) != 0 && bytes.Compare(sk.From, sk.To) > 0 {
+		return fmt.Errorf("invalid sort key predicate: from %q is greater than to %q", sk.From, sk.To)
+	}
+
+	return nil
+}
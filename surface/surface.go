@@ -11,26 +11,28 @@ package surface
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"encoding/hex"
+	"fmt"
 
 	"github.com/fogfish/curie"
 	"github.com/fogfish/gurl/v2/http"
-	ƒ "github.com/fogfish/gurl/v2/http/recv"
 	ø "github.com/fogfish/gurl/v2/http/send"
+	"github.com/kshard/optimum"
+	"github.com/kshard/optimum/internal/batch"
 	"github.com/kshard/wreck"
 )
 
 // Client for reading/writing Graph-based Nearest Neighbor Surface.
 type Client struct {
 	http.Stack
-	host ø.Authority
+	*transport
 }
 
 // Creates the client for reading/writing Graph-based Nearest Neighbor Surface.
-func New(stack http.Stack, host string) *Client {
+func New(stack http.Stack, host string, opts ...Option) *Client {
 	return &Client{
-		Stack: stack,
-		host:  ø.Authority(host),
+		Stack:     stack,
+		transport: newTransport(host, opts),
 	}
 }
 
@@ -41,37 +43,99 @@ func (api *Client) Write(ctx context.Context, cask curie.IRI, bag []Vector) erro
 	}
 
 	var buf bytes.Buffer
-	out := wreck.NewWriterJSON(&buf, false)
-	seq := wreck.NewWriter[float32](out)
+	seq := wreck.NewWriter[float32](&buf)
+
+	dimension := api.dimension
+	if dimension == 0 {
+		dimension = len(bag[0].Vector)
+	}
 
 	for _, vec := range bag {
+		if len(vec.Vector) != dimension {
+			return &DimensionError{UniqueKey: vec.UniqueKey, Expected: dimension, Actual: len(vec.Vector)}
+		}
+
 		if err := seq.Write(vec.UniqueKey, vec.SortKey, vec.Vector); err != nil {
 			return err
 		}
 	}
 
-	if err := out.Close(); err != nil {
-		return err
+	return api.send(ctx, api.Stack, cask, "object", buf.Bytes(), false)
+}
+
+// Get the vector stored at unique key, the key is hex encoded in the path.
+// It returns error that matches optimum.ErrNotFound if the key does not exist.
+func (api *Client) Get(ctx context.Context, cask curie.IRI, key []uint8) (*Vector, error) {
+	return http.IO[Vector](
+		api.WithContext(ctx),
+		http.GET(
+			ø.URI("%s/ds/%s/%s/object/%s", api.host, curie.Prefix(cask), curie.Reference(cask), hex.EncodeToString(key)),
+			ø.Accept.JSON,
+
+			optimum.Code(http.StatusOK),
+		),
+	)
+}
+
+// MultiGet vectors stored at unique keys, missing keys are omitted from
+// the result.
+func (api *Client) MultiGet(ctx context.Context, cask curie.IRI, keys [][]uint8) ([]Vector, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	bag, err := http.IO[struct {
+		V []Vector `json:"object"`
+	}](
+		api.WithContext(ctx),
+		http.GET(
+			ø.URI("%s/ds/%s/%s/object", api.host, curie.Prefix(cask), curie.Reference(cask)),
+			ø.Accept.JSON,
+			ø.ContentType.JSON,
+			ø.Send(struct {
+				K [][]uint8 `json:"keys"`
+			}{
+				K: keys,
+			}),
+
+			optimum.Code(http.StatusOK),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return bag.V, nil
+}
+
+// Delete vector(s) by unique key
+func (api *Client) Delete(ctx context.Context, cask curie.IRI, keys [][]uint8) error {
+	if len(keys) == 0 {
+		return nil
 	}
 
 	return api.IO(ctx,
-		http.POST(
+		http.DELETE(
 			ø.URI("%s/ds/%s/%s/object", api.host, curie.Prefix(cask), curie.Reference(cask)),
 			ø.Accept.JSON,
 			ø.ContentType.JSON,
 			ø.Send(struct {
-				V json.RawMessage `json:"object"`
+				K [][]uint8 `json:"keys"`
 			}{
-				V: buf.Bytes(),
+				K: keys,
 			}),
 
-			ƒ.Status.Accepted,
+			optimum.Code(http.StatusOK, http.StatusAccepted, http.StatusNoContent),
 		),
 	)
 }
 
 // Query nearest neighbor points to the given vector
 func (api *Client) Query(ctx context.Context, cask curie.IRI, q Query) (*Result, error) {
+	if err := api.validate(q); err != nil {
+		return nil, err
+	}
+
 	return http.IO[Result](
 		api.WithContext(ctx),
 		http.GET(
@@ -84,7 +148,94 @@ func (api *Client) Query(ctx context.Context, cask curie.IRI, q Query) (*Result,
 				Q: q,
 			}),
 
-			ƒ.Status.OK,
+			optimum.Code(http.StatusOK),
 		),
 	)
 }
+
+// QueryBatch evaluates queries within one request, results are aligned with
+// queries. The client falls back to concurrent queries (see WithConcurrency)
+// if server does not support batches.
+func (api *Client) QueryBatch(ctx context.Context, cask curie.IRI, seq []Query) ([]Result, error) {
+	if len(seq) == 0 {
+		return nil, nil
+	}
+
+	for _, q := range seq {
+		if err := api.validate(q); err != nil {
+			return nil, err
+		}
+	}
+
+	ambiguous := false
+	if !api.nobatch.Load() {
+		rs, err := api.queryBatch(ctx, cask, seq)
+		switch {
+		case batch.Unsupported(err):
+			api.nobatch.Store(true)
+		case batch.Ambiguous(err):
+			ambiguous = true
+		default:
+			return rs, err
+		}
+	}
+
+	rs, err := batch.Map(ctx, api.concurrency, seq,
+		func(ctx context.Context, q Query) (Result, error) {
+			rs, err := api.Query(ctx, cask, q)
+			if err != nil {
+				return Result{}, err
+			}
+			return *rs, nil
+		},
+	)
+	if ambiguous && err == nil {
+		api.nobatch.Store(true)
+	}
+
+	return rs, err
+}
+
+func (api *Client) queryBatch(ctx context.Context, cask curie.IRI, seq []Query) ([]Result, error) {
+	rs, err := http.IO[struct {
+		Results []Result `json:"results"`
+	}](
+		api.WithContext(ctx),
+		http.GET(
+			ø.URI("%s/ds/%s/%s/queries", api.host, curie.Prefix(cask), curie.Reference(cask)),
+			ø.Accept.JSON,
+			ø.ContentType.JSON,
+			ø.Send(struct {
+				Q []Query `json:"queries"`
+			}{
+				Q: seq,
+			}),
+
+			optimum.Code(http.StatusOK),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rs.Results) != len(seq) {
+		return nil, fmt.Errorf("batch of %d queries returns %d results", len(seq), len(rs.Results))
+	}
+
+	return rs.Results, nil
+}
+
+// query is validated before sending
+func (api *Client) validate(q Query) error {
+	if api.dimension != 0 && len(q.Query) != api.dimension {
+		return &DimensionError{Expected: api.dimension, Actual: len(q.Query)}
+	}
+
+	if q.SortKey != nil {
+		if err := q.SortKey.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package surface
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fogfish/curie"
+	"github.com/fogfish/gurl/v2/http"
+	"github.com/kshard/optimum"
+)
+
+// queries responds to batch with the status, single queries are responded
+// with the rank equal to the first component of the query
+type queries struct {
+	sync.Mutex
+	batch  int
+	single int
+	log    []string
+}
+
+func (s *queries) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	b, _ := io.ReadAll(r.Body)
+
+	if strings.HasSuffix(r.URL.Path, "/queries") {
+		s.log = append(s.log, "batch")
+		w.WriteHeader(s.batch)
+		return
+	}
+
+	s.log = append(s.log, "query")
+	if s.single != nethttp.StatusOK {
+		w.WriteHeader(s.single)
+		return
+	}
+
+	var req struct {
+		Q Query `json:"query"`
+	}
+	json.Unmarshal(b, &req)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Result{Hits: []Hit{{Rank: req.Q.Query[0]}}})
+}
+
+func (s *queries) requests() string {
+	s.Lock()
+	defer s.Unlock()
+	return strings.Join(s.log, " ")
+}
+
+func TestQueryBatchFallback(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		srv    *queries
+		failed int
+		log    string
+	}{
+		{
+			name: "method is not allowed",
+			srv:  &queries{batch: 405, single: 200},
+			log:  "batch query query query query",
+		},
+		{
+			name: "batch is not implemented",
+			srv:  &queries{batch: 501, single: 200},
+			log:  "batch query query query query",
+		},
+		{
+			name: "batch is not found",
+			srv:  &queries{batch: 404, single: 200},
+			log:  "batch query query query query",
+		},
+		{
+			name:   "cask is not found",
+			srv:    &queries{batch: 404, single: 404},
+			failed: 404,
+			log:    "batch query batch query",
+		},
+		{
+			name:   "batch is invalid",
+			srv:    &queries{batch: 400, single: 200},
+			failed: 400,
+			log:    "batch batch",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.srv)
+			defer ts.Close()
+
+			api := New(http.New(), ts.URL, WithConcurrency(1))
+			seq := []Query{{Query: []float32{1, 0}}, {Query: []float32{2, 0}}}
+
+			for i := 0; i < 2; i++ {
+				rs, err := api.QueryBatch(context.Background(), curie.New("hnsw:example"), seq)
+
+				var e *optimum.Error
+				switch {
+				case tt.failed == 0 && err != nil:
+					t.Fatalf("unexpected error %v", err)
+				case tt.failed != 0 && (!errors.As(err, &e) || e.StatusCode != tt.failed):
+					t.Fatalf("unexpected error %v, expected status %d", err, tt.failed)
+				case tt.failed == 0 && (len(rs) != 2 || rs[0].Hits[0].Rank != 1 || rs[1].Hits[0].Rank != 2):
+					t.Fatalf("results are not aligned with queries %+v", rs)
+				}
+			}
+
+			if log := tt.srv.requests(); log != tt.log {
+				t.Errorf("unexpected requests %q, expected %q", log, tt.log)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package surface
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fogfish/curie"
+	"github.com/fogfish/gurl/v2/http"
+	ø "github.com/fogfish/gurl/v2/http/send"
+	"github.com/kshard/optimum"
+	"github.com/kshard/wreck"
+)
+
+// Format of vectors sent to server
+type Format int
+
+const (
+	// Binary format is used if server advertises it (Accept-Post header of
+	// OPTIONS response), otherwise the client uses JSON format. The client
+	// falls back to JSON format if server rejects binary records as
+	// unsupported media type. If server does not support OPTIONS, the binary
+	// format is tried, any rejection of records by the first requests falls
+	// back to JSON format.
+	FormatAuto Format = iota
+
+	// Vectors are sent as JSON document, binary records are base64 encoded.
+	FormatJSON
+
+	// Vectors are sent as binary records (wreck).
+	FormatBinary
+)
+
+// Media type of binary records (wreck)
+const MediaTypeWreck = "application/x-wreck"
+
+// Option of the client and writer
+type Option func(*options)
+
+type options struct {
+	parallel    int
+	format      Format
+	compression optimum.Compression
+	level       int
+	dimension   int
+	concurrency int
+}
+
+var defaultOptions = options{
+	parallel:    1,
+	format:      FormatJSON,
+	concurrency: 8,
+}
+
+// WithParallel sets the number of chunks uploaded concurrently by writer.
+// The memory used by writer is bounded by n+1 chunks. The chunks are
+// sequentially uploaded by default.
+func WithParallel(n int) Option {
+	return func(opts *options) {
+		opts.parallel = n
+	}
+}
+
+// WithFormat sets the format of vectors sent to server, FormatJSON is used
+// by default.
+func WithFormat(format Format) Option {
+	return func(opts *options) {
+		opts.format = format
+	}
+}
+
+// WithCompression sets the compression of binary records. The level is
+// specific to the algorithm, zero value is the default level. Binary records
+// are not compressed by default.
+func WithCompression(c optimum.Compression, level int) Option {
+	return func(opts *options) {
+		opts.compression = c
+		opts.level = level
+	}
+}
+
+// WithDimension sets the dimension of vectors written or queried by clients.
+// Otherwise, the writer locks the dimension on the first vector.
+func WithDimension(n int) Option {
+	return func(opts *options) {
+		opts.dimension = n
+	}
+}
+
+// WithConcurrency sets the number of concurrent queries, which are used by
+// QueryBatch if server does not support batches. The default is 8.
+func WithConcurrency(n int) Option {
+	return func(opts *options) {
+		opts.concurrency = n
+	}
+}
+
+// DimensionError is returned when the vector does not match the dimension
+type DimensionError struct {
+	UniqueKey []uint8
+	Expected  int
+	Actual    int
+}
+
+func (e *DimensionError) Error() string {
+	if e.UniqueKey == nil {
+		return fmt.Sprintf("vector has dimension %d, expected %d", e.Actual, e.Expected)
+	}
+	return fmt.Sprintf("vector %q has dimension %d, expected %d", e.UniqueKey, e.Actual, e.Expected)
+}
+
+//------------------------------------------------------------------------------
+
+// format negotiated with server
+const (
+	wireUnknown int32 = iota
+	wireBinary
+	wireJSON
+)
+
+// transport of vectors to server
+type transport struct {
+	options
+	host ø.Authority
+
+	// format supported by server, it is probed once
+	probe sync.Once
+	wire  atomic.Int32
+
+	// server does not support batch queries
+	nobatch atomic.Bool
+}
+
+func newTransport(host string, opts []Option) *transport {
+	t := &transport{options: defaultOptions, host: ø.Authority(host)}
+	for _, opt := range opts {
+		opt(&t.options)
+	}
+
+	return t
+}
+
+// send binary records to the resource of the cask, the JSON document is
+// gzip-ed if requested.
+func (t *transport) send(ctx context.Context, stack http.Stack, cask curie.IRI, resource string, records []byte, withGzip bool) error {
+	if t.format == FormatAuto {
+		t.probe.Do(func() {
+			t.wire.Store(t.advertised(ctx, stack, cask, resource))
+		})
+	}
+
+	if t.format == FormatJSON || (t.format == FormatAuto && t.wire.Load() == wireJSON) {
+		return t.sendJSON(ctx, stack, cask, resource, records, withGzip)
+	}
+
+	err := t.sendBinary(ctx, stack, cask, resource, records)
+	if t.format != FormatAuto {
+		return err
+	}
+
+	if err == nil {
+		t.wire.CompareAndSwap(wireUnknown, wireBinary)
+		return nil
+	}
+
+	// the bad request is the payload error once server accepts binary records
+	var e *optimum.Error
+	if errors.As(err, &e) && (e.StatusCode == nethttp.StatusUnsupportedMediaType ||
+		(e.StatusCode == nethttp.StatusBadRequest && t.wire.Load() == wireUnknown)) {
+		t.wire.Store(wireJSON)
+		return t.sendJSON(ctx, stack, cask, resource, records, withGzip)
+	}
+
+	return err
+}
+
+// server advertises binary format with Accept-Post header of OPTIONS response.
+// The format is unknown if server does not support OPTIONS, the client uses
+// JSON format if the probe fails otherwise.
+func (t *transport) advertised(ctx context.Context, stack http.Stack, cask curie.IRI, resource string) int32 {
+	var accept []string
+
+	err := stack.IO(optimum.Idempotent(ctx),
+		http.Join(
+			ø.Method(nethttp.MethodOptions),
+			ø.URI("%s/ds/%s/%s/%s", t.host, curie.Prefix(cask), curie.Reference(cask), resource),
+
+			optimum.Code(http.StatusOK, http.StatusNoContent),
+			func(cat *http.Context) error {
+				accept = cat.Response.Header.Values("Accept-Post")
+				return nil
+			},
+		),
+	)
+
+	var e *optimum.Error
+	switch {
+	case errors.As(err, &e) && (e.StatusCode == nethttp.StatusNotFound ||
+		e.StatusCode == nethttp.StatusMethodNotAllowed ||
+		e.StatusCode == nethttp.StatusNotImplemented):
+		return wireUnknown
+	case err != nil:
+		return wireJSON
+	}
+
+	for _, header := range accept {
+		for _, media := range strings.Split(header, ",") {
+			media, _, _ = strings.Cut(media, ";")
+			if strings.EqualFold(strings.TrimSpace(media), MediaTypeWreck) {
+				return wireBinary
+			}
+		}
+	}
+
+	return wireJSON
+}
+
+func (t *transport) sendBinary(ctx context.Context, stack http.Stack, cask curie.IRI, resource string, records []byte) error {
+	var buf bytes.Buffer
+
+	w, err := t.compression.NewWriter(&buf, t.level)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(records); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	req := []http.Arrow{
+		ø.URI("%s/ds/%s/%s/%s", t.host, curie.Prefix(cask), curie.Reference(cask), resource),
+		ø.Accept.JSON,
+		ø.ContentType.Set(MediaTypeWreck),
+	}
+	if t.compression != optimum.Identity {
+		req = append(req, ø.Header("Content-Encoding", string(t.compression)))
+	}
+	req = append(req,
+		ø.Send(buf.Bytes()),
+
+		optimum.Code(http.StatusAccepted),
+	)
+
+	// vectors are identified by unique key, the write is safe to retry
+	return stack.IO(optimum.Idempotent(ctx), http.POST(req...))
+}
+
+func (t *transport) sendJSON(ctx context.Context, stack http.Stack, cask curie.IRI, resource string, records []byte, withGzip bool) error {
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"object":`)
+	w := wreck.NewWriterJSON(&buf, withGzip)
+	if _, err := w.Write(records); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	buf.WriteString(`}`)
+
+	// vectors are identified by unique key, the write is safe to retry
+	return stack.IO(optimum.Idempotent(ctx),
+		http.POST(
+			ø.URI("%s/ds/%s/%s/%s", t.host, curie.Prefix(cask), curie.Reference(cask), resource),
+			ø.Accept.JSON,
+			ø.ContentType.JSON,
+			ø.Send(buf.Bytes()),
+
+			optimum.Code(http.StatusAccepted),
+		),
+	)
+}
@@ -0,0 +1,184 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package surface
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fogfish/curie"
+	"github.com/fogfish/gurl/v2/http"
+	"github.com/kshard/optimum"
+)
+
+// server accepts JSON documents, binary records are responded with codes of
+// the sequence, the last one is repeated
+type server struct {
+	sync.Mutex
+	options int
+	accept  string
+	binary  []int
+	json    int
+	log     []string
+}
+
+func (s *server) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if r.Method == nethttp.MethodOptions {
+		s.log = append(s.log, "options")
+		if s.accept != "" {
+			w.Header().Set("Accept-Post", s.accept)
+		}
+		w.WriteHeader(s.options)
+		return
+	}
+
+	switch r.Header.Get("Content-Type") {
+	case MediaTypeWreck:
+		code := s.binary[min(s.count("binary"), len(s.binary)-1)]
+		s.log = append(s.log, "binary")
+		w.WriteHeader(code)
+	default:
+		s.log = append(s.log, "json")
+		w.WriteHeader(s.json)
+	}
+}
+
+func (s *server) count(kind string) int {
+	n := 0
+	for _, x := range s.log {
+		if x == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *server) requests() []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string{}, s.log...)
+}
+
+func TestTransportFormat(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		format Format
+		srv    *server
+		writes int
+		failed int
+		log    []string
+	}{
+		{
+			name:   "json by default",
+			format: FormatJSON,
+			srv:    &server{options: 200, accept: MediaTypeWreck, binary: []int{202}, json: 202},
+			writes: 2,
+			log:    []string{"json", "json"},
+		},
+		{
+			name:   "binary is advertised",
+			format: FormatAuto,
+			srv:    &server{options: 200, accept: "application/json, application/x-wreck;q=0.9", binary: []int{202}, json: 202},
+			writes: 2,
+			log:    []string{"options", "binary", "binary"},
+		},
+		{
+			name:   "binary is not advertised",
+			format: FormatAuto,
+			srv:    &server{options: 204, accept: "application/json", binary: []int{202}, json: 202},
+			writes: 2,
+			log:    []string{"options", "json", "json"},
+		},
+		{
+			name:   "probe fails",
+			format: FormatAuto,
+			srv:    &server{options: 403, binary: []int{202}, json: 202},
+			writes: 2,
+			log:    []string{"options", "json", "json"},
+		},
+		{
+			name:   "advertised binary is unsupported",
+			format: FormatAuto,
+			srv:    &server{options: 200, accept: MediaTypeWreck, binary: []int{415}, json: 202},
+			writes: 2,
+			log:    []string{"options", "binary", "json", "json"},
+		},
+		{
+			name:   "advertised binary is invalid",
+			format: FormatAuto,
+			srv:    &server{options: 200, accept: MediaTypeWreck, binary: []int{400}, json: 202},
+			writes: 1,
+			failed: 400,
+			log:    []string{"options", "binary"},
+		},
+		{
+			name:   "probe-less binary is rejected",
+			format: FormatAuto,
+			srv:    &server{options: 405, binary: []int{400}, json: 202},
+			writes: 2,
+			log:    []string{"options", "binary", "json", "json"},
+		},
+		{
+			name:   "probe-less binary is unsupported",
+			format: FormatAuto,
+			srv:    &server{options: 501, binary: []int{415}, json: 202},
+			writes: 2,
+			log:    []string{"options", "binary", "json", "json"},
+		},
+		{
+			name:   "probe-less binary is accepted",
+			format: FormatAuto,
+			srv:    &server{options: 405, binary: []int{202, 400}, json: 202},
+			writes: 2,
+			failed: 400,
+			log:    []string{"options", "binary", "binary"},
+		},
+		{
+			name:   "binary is forced",
+			format: FormatBinary,
+			srv:    &server{options: 200, binary: []int{415}, json: 202},
+			writes: 1,
+			failed: 415,
+			log:    []string{"binary"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.srv)
+			defer ts.Close()
+
+			api := New(http.New(), ts.URL, WithFormat(tt.format))
+			bag := []Vector{{UniqueKey: []byte("k"), Vector: []float32{1, 2}}}
+
+			var err error
+			for i := 0; i < tt.writes && err == nil; i++ {
+				err = api.Write(context.Background(), curie.New("hnsw:example"), bag)
+			}
+
+			var e *optimum.Error
+			switch {
+			case tt.failed == 0 && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case tt.failed != 0 && (!errors.As(err, &e) || e.StatusCode != tt.failed):
+				t.Errorf("unexpected error %v, expected status %d", err, tt.failed)
+			}
+
+			if log := tt.srv.requests(); !slices.Equal(log, tt.log) {
+				t.Errorf("unexpected requests %v, expected %v", log, tt.log)
+			}
+		})
+	}
+}
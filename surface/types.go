@@ -27,6 +27,22 @@ type Query struct {
 	EfSearch int       `json:"efSearch,omitempty"`
 	Distance float32   `json:"distance,omitempty"`
 	Query    []float32 `json:"query"`
+	SortKey  *SortKey  `json:"sk,omitempty"`
+}
+
+// SortKey predicate restricts the query to vectors with matching sort key,
+// e.g. partition of tenant or time. The prefix and the range are combined
+// if both are defined. Use builders (SortKeyPrefix, SortKeyBetween,
+// SortKeyFrom, SortKeyTo) to construct the predicate.
+type SortKey struct {
+	// Sort key starts with the prefix
+	Prefix []uint8 `json:"prefix,omitempty"`
+
+	// Sort key is greater or equal to the bound (inclusive)
+	From []uint8 `json:"from,omitempty"`
+
+	// Sort key is less or equal to the bound (inclusive)
+	To []uint8 `json:"to,omitempty"`
 }
 
 // Results from query
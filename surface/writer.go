@@ -11,37 +11,40 @@ package surface
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"io"
 
 	"github.com/fogfish/curie"
 	"github.com/fogfish/gurl/v2/http"
-	ƒ "github.com/fogfish/gurl/v2/http/recv"
-	ø "github.com/fogfish/gurl/v2/http/send"
+	"github.com/kshard/optimum/internal/chunks"
 	"github.com/kshard/wreck"
 )
 
 // Client for streaming to Graph-based Nearest Neighbor Search Algorithms
 type Writer struct {
 	http.Stack
+	*transport
 
-	host ø.Authority
 	cask curie.IRI
 
 	chunk int
 	buf   bytes.Buffer
-	out   io.WriteCloser
 	seq   *wreck.Writer[float32]
+
+	// offset of input written to buffer
+	offset int64
+
+	pipe *chunks.Pipeline
 }
 
 // Creates the client for streaming to Graph-based Nearest Neighbor Search Algorithms
-func NewWriter(stack http.Stack, host string, cask curie.IRI, chunk int) *Writer {
+func NewWriter(stack http.Stack, host string, cask curie.IRI, chunk int, opts ...Option) *Writer {
 	stream := &Writer{
-		Stack: stack,
-		host:  ø.Authority(host),
-		cask:  cask,
-		chunk: chunk,
+		Stack:     stack,
+		transport: newTransport(host, opts),
+		cask:      cask,
+		chunk:     chunk,
 	}
+
+	stream.pipe = chunks.New(stream.parallel)
 	stream.reset()
 
 	return stream
@@ -49,47 +52,101 @@ func NewWriter(stack http.Stack, host string, cask curie.IRI, chunk int) *Writer
 
 func (stream *Writer) reset() {
 	stream.buf.Reset()
-	stream.out = wreck.NewWriterJSON(&stream.buf, true)
-	stream.seq = wreck.NewWriter[float32](stream.out)
+	stream.seq = wreck.NewWriter[float32](&stream.buf)
 }
 
-// Write vector
+// Write vector, the offset of input is advanced by one.
 func (stream *Writer) Write(ctx context.Context, v Vector) error {
+	return stream.WriteAt(ctx, v, stream.offset+1)
+}
+
+// WriteAt writes vector read from the input at given offset. The offset is
+// the position of input right after the vector (e.g. bytes or lines consumed
+// from the file). Use Offset to find out which part of input is acknowledged
+// by the server.
+func (stream *Writer) WriteAt(ctx context.Context, v Vector, offset int64) error {
+	if err := stream.pipe.Err(); err != nil {
+		return err
+	}
+
+	switch {
+	case stream.dimension == 0:
+		stream.dimension = len(v.Vector)
+	case stream.dimension != len(v.Vector):
+		return &DimensionError{UniqueKey: v.UniqueKey, Expected: stream.dimension, Actual: len(v.Vector)}
+	}
+
 	if err := stream.seq.Write(v.UniqueKey, v.SortKey, v.Vector); err != nil {
 		return err
 	}
+	stream.offset = offset
 
 	if stream.buf.Len() >= stream.chunk {
-		return stream.Sync(ctx)
+		return stream.flush(ctx)
 	}
 
 	return nil
 }
 
-// Sync local cache
-func (stream *Writer) Sync(ctx context.Context) (err error) {
-	defer stream.reset()
+// Delete vector, the offset of input is advanced by one.
+func (stream *Writer) Delete(ctx context.Context, key []uint8) error {
+	return stream.DeleteAt(ctx, key, stream.offset+1)
+}
 
-	if err := stream.out.Close(); err != nil {
+// DeleteAt writes tombstone of the vector read from the input at given
+// offset. The tombstone is the record without vector, it is batched together
+// with vectors.
+func (stream *Writer) DeleteAt(ctx context.Context, key []uint8, offset int64) error {
+	if err := stream.pipe.Err(); err != nil {
 		return err
 	}
 
+	if err := stream.seq.Write(key, nil, nil); err != nil {
+		return err
+	}
+	stream.offset = offset
+
+	if stream.buf.Len() >= stream.chunk {
+		return stream.flush(ctx)
+	}
+
+	return nil
+}
+
+// Sync local cache, it waits for all chunks in-flight even if the cache is
+// not sent. The error of the earliest failed chunk is preferred.
+func (stream *Writer) Sync(ctx context.Context) error {
+	err := stream.flush(ctx)
+	if failed := stream.pipe.Wait(); failed != nil {
+		return failed
+	}
+
+	return err
+}
+
+// Close the writer, it syncs local cache and waits for all chunks in-flight.
+// The error of the earliest failed chunk is returned.
+func (stream *Writer) Close(ctx context.Context) error {
+	return stream.Sync(ctx)
+}
+
+// Offset of input acknowledged by the server, the input before the offset
+// is safe to skip when the upload is resumed.
+func (stream *Writer) Offset() int64 { return stream.pipe.Offset() }
+
+// sends the chunk of local cache
+func (stream *Writer) flush(ctx context.Context) error {
+	defer stream.reset()
+
 	if stream.buf.Len() == 0 {
 		return nil
 	}
 
-	return stream.Stack.IO(ctx,
-		http.POST(
-			ø.URI("%s/ds/%s/%s/objects", stream.host, curie.Prefix(stream.cask), curie.Reference(stream.cask)),
-			ø.Accept.JSON,
-			ø.ContentType.JSON,
-			ø.Send(struct {
-				V json.RawMessage `json:"object"`
-			}{
-				V: stream.buf.Bytes(),
-			}),
-
-			ƒ.Status.Accepted,
-		),
+	chunk := bytes.Clone(stream.buf.Bytes())
+
+	return stream.pipe.Send(ctx, stream.offset,
+		func(ctx context.Context) error {
+			return stream.send(ctx, stream.Stack, stream.cask, "objects", chunk, true)
+		},
 	)
 }
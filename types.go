@@ -9,6 +9,7 @@
 package optimum
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/fogfish/curie"
@@ -17,6 +18,9 @@ import (
 
 type Instances struct {
 	Items []Instance `json:"items,omitempty"`
+
+	// Cursor to the next page, empty if it is the last page
+	Next string `json:"next,omitempty"`
 }
 
 type Instance struct {
@@ -26,11 +30,27 @@ type Instance struct {
 	Updated time.Time `json:"updated"`
 	Version string    `json:"version"`
 	Pending string    `json:"pending"`
+
+	// The latest job spawned for the instance (e.g. create, commit)
+	Job schemaorg.Url `json:"job,omitempty"`
+
+	// Dimension of vectors, if it is reported by the server
+	Dimension int `json:"dimension,omitempty"`
+}
+
+// DecodeOpts decodes configuration of the instance into the typed config
+// of the data structure (e.g. surface.Config).
+func (inst Instance) DecodeOpts(opts any) error {
+	if len(inst.Opts) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(inst.Opts), opts)
 }
 
 type create struct {
-	Name string         `json:"name"`
-	Opts map[string]any `json:"opts"`
+	Name string `json:"name"`
+	Opts any    `json:"opts"`
 }
 
 type Created struct {
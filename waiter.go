@@ -0,0 +1,120 @@
+//
+// Copyright (C) 2024 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/kshard/optimum
+//
+
+package optimum
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/fogfish/schemaorg"
+)
+
+// Terminal statuses of the job
+const (
+	StatusSucceeded = "SUCCEEDED"
+	StatusFailed    = "FAILED"
+)
+
+// Default polling intervals used by WaitJob
+const (
+	DefaultInitialInterval = 5 * time.Second
+	DefaultMaxInterval     = 60 * time.Second
+)
+
+// WaitOptions configures polling of the job status.
+type WaitOptions struct {
+	// Interval before the first status check, it is doubled after each
+	// check until MaxInterval is reached.
+	InitialInterval time.Duration
+
+	// Upper bound of the interval between status checks.
+	MaxInterval time.Duration
+
+	// Deadline for the job to complete, zero value disables the deadline.
+	Timeout time.Duration
+
+	// Progress is called with each observed status of the job.
+	Progress func(*JobStatus)
+}
+
+// JobFailed is returned by WaitJob when the job is completed with failure.
+type JobFailed struct {
+	Job    schemaorg.Url
+	Status *JobStatus
+}
+
+func (e *JobFailed) Error() string {
+	if e.Status == nil || e.Status.Reason == "" {
+		return fmt.Sprintf("job %s failed", e.Job)
+	}
+
+	return fmt.Sprintf("job %s failed: %s", e.Job, e.Status.Reason)
+}
+
+// WaitJob blocks until the job is completed, the context is cancelled or
+// the timeout is expired. The status of the job is polled with exponential
+// backoff and jitter. It returns JobFailed error if the job is failed.
+func (api *Client) WaitJob(ctx context.Context, job schemaorg.Url, opts WaitOptions) (*JobStatus, error) {
+	if opts.InitialInterval <= 0 {
+		opts.InitialInterval = DefaultInitialInterval
+	}
+
+	if opts.MaxInterval < opts.InitialInterval {
+		opts.MaxInterval = max(DefaultMaxInterval, opts.InitialInterval)
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	interval := opts.InitialInterval
+	timer := time.NewTimer(jitter(interval))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		status, err := api.Status(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(status)
+		}
+
+		switch status.Status {
+		case StatusSucceeded:
+			return status, nil
+		case StatusFailed:
+			return status, &JobFailed{Job: job, Status: status}
+		}
+
+		interval = min(2*interval, opts.MaxInterval)
+		timer.Reset(jitter(interval))
+	}
+}
+
+// equal jitter, the interval is randomized within [t/2, t)
+func jitter(t time.Duration) time.Duration {
+	half := t / 2
+	if half <= 0 {
+		return t
+	}
+
+	return half + rand.N(half)
+}